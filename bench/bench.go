@@ -0,0 +1,204 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bench holds benchmarks which compare the speed, the
+// allocations, and the output size of cork with encoding/gob
+// and encoding/json, using the same values for each codec.
+//
+// Run them from the root of the repository with:
+//
+//	go test -run '^$' -bench . -benchmem ./bench
+//
+// Each benchmark reports the size of the encoded value as
+// bytes/value, alongside the usual time and allocations.
+package bench
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/surrealdb/cork"
+)
+
+// Complex mirrors the Complex test fixture in the cork
+// package, without the complex number fields and maps
+// with interface keys, which encoding/json can't encode.
+// It has no Selfer methods, so cork uses reflection.
+type Complex struct {
+	Bool            bool
+	String          string
+	Bytes           []byte
+	Time            time.Time
+	Int             int
+	Int8            int8
+	Int16           int16
+	Int32           int32
+	Int64           int64
+	Uint            uint
+	Uint8           uint8
+	Uint16          uint16
+	Uint32          uint32
+	Uint64          uint64
+	Float32         float32
+	Float64         float64
+	ArrBool         []bool
+	ArrString       []string
+	ArrInt          []int
+	ArrInt64        []int64
+	ArrUint64       []uint64
+	ArrFloat64      []float64
+	ArrTime         []time.Time
+	MapStringInt    map[string]int
+	MapStringBool   map[string]bool
+	MapStringString map[string]string
+}
+
+// Small is a small struct, of the kind which is typically
+// encoded as a single record or message.
+type Small struct {
+	ID    int64  `cork:"id" json:"id"`
+	Name  string `cork:"name" json:"name"`
+	Admin bool   `cork:"admin" json:"admin"`
+}
+
+type codec struct {
+	name string
+	enc  func(v interface{}) ([]byte, error)
+	dec  func(b []byte, v interface{}) error
+}
+
+// codecs are the codecs which are compared. The gob codec
+// uses a new Encoder and Decoder for each value, as a value
+// stored or sent on its own must include its type details.
+var codecs = []codec{
+	{
+		name: "cork",
+		enc: func(v interface{}) ([]byte, error) {
+			var b []byte
+			err := cork.NewEncoderBytes(&b).Encode(v)
+			return b, err
+		},
+		dec: func(b []byte, v interface{}) error {
+			return cork.NewDecoderBytes(b).Decode(v)
+		},
+	},
+	{
+		name: "corkpool",
+		enc: func(v interface{}) ([]byte, error) {
+			var b []byte
+			e := cork.NewEncoderBytesFromPool(&b)
+			err := e.Encode(v)
+			e.Reset()
+			return b, err
+		},
+		dec: func(b []byte, v interface{}) error {
+			d := cork.NewDecoderBytesFromPool(b)
+			err := d.Decode(v)
+			d.Reset()
+			return err
+		},
+	},
+	{
+		name: "gob",
+		enc: func(v interface{}) ([]byte, error) {
+			var b bytes.Buffer
+			err := gob.NewEncoder(&b).Encode(v)
+			return b.Bytes(), err
+		},
+		dec: func(b []byte, v interface{}) error {
+			return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+		},
+	},
+	{
+		name: "json",
+		enc:  json.Marshal,
+		dec:  json.Unmarshal,
+	},
+}
+
+type fixture struct {
+	name string
+	val  interface{}
+	new  func() interface{}
+}
+
+// fixtures are the values which are encoded and decoded.
+func fixtures() []fixture {
+
+	tme, _ := time.Parse(time.RFC3339, "1987-06-22T08:00:00.123456789Z")
+
+	slice := make([]Small, 10000)
+	for i := range slice {
+		slice[i] = Small{ID: int64(i), Name: "name" + strconv.Itoa(i), Admin: i%2 == 0}
+	}
+
+	hash := make(map[string]Small, 10000)
+	for i := range slice {
+		hash[slice[i].Name] = slice[i]
+	}
+
+	return []fixture{
+		{
+			name: "Complex",
+			val: &Complex{
+				Bool:            true,
+				String:          "Hello World",
+				Bytes:           []byte("Hello World"),
+				Time:            tme,
+				Int:             1,
+				Int8:            -8,
+				Int16:           -16,
+				Int32:           -32,
+				Int64:           -64,
+				Uint:            1,
+				Uint8:           8,
+				Uint16:          16,
+				Uint32:          32,
+				Uint64:          64,
+				Float32:         32.5,
+				Float64:         64.5,
+				ArrBool:         []bool{true, false},
+				ArrString:       []string{"one", "two", "three"},
+				ArrInt:          []int{1, 2, 3},
+				ArrInt64:        []int64{1, 2, 3},
+				ArrUint64:       []uint64{1, 2, 3},
+				ArrFloat64:      []float64{1.5, 2.5, 3.5},
+				ArrTime:         []time.Time{tme, tme},
+				MapStringInt:    map[string]int{"one": 1, "two": 2},
+				MapStringBool:   map[string]bool{"one": true, "two": false},
+				MapStringString: map[string]string{"one": "1", "two": "2"},
+			},
+			new: func() interface{} { return new(Complex) },
+		},
+		{
+			name: "Small",
+			val:  &Small{ID: 1, Name: "Tobie", Admin: true},
+			new:  func() interface{} { return new(Small) },
+		},
+		{
+			name: "Slice",
+			val:  &slice,
+			new:  func() interface{} { return new([]Small) },
+		},
+		{
+			name: "Map",
+			val:  &hash,
+			new:  func() interface{} { return new(map[string]Small) },
+		},
+	}
+
+}
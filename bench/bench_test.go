@@ -0,0 +1,75 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	for _, f := range fixtures() {
+		for _, c := range codecs {
+			enc, err := c.enc(f.val)
+			if err != nil {
+				t.Fatalf("%s: can't encode %s: %v", c.name, f.name, err)
+			}
+			dec := f.new()
+			if err := c.dec(enc, dec); err != nil {
+				t.Fatalf("%s: can't decode %s: %v", c.name, f.name, err)
+			}
+			if !reflect.DeepEqual(reflect.ValueOf(dec).Elem().Interface(), reflect.ValueOf(f.val).Elem().Interface()) {
+				t.Fatalf("%s: %s did not round trip", c.name, f.name)
+			}
+		}
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	for _, f := range fixtures() {
+		for _, c := range codecs {
+			f, c := f, c
+			b.Run(f.name+"/"+c.name, func(b *testing.B) {
+				var enc []byte
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					enc, _ = c.enc(f.val)
+				}
+				b.ReportMetric(float64(len(enc)), "bytes/value")
+			})
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, f := range fixtures() {
+		for _, c := range codecs {
+			f, c := f, c
+			enc, err := c.enc(f.val)
+			if err != nil {
+				b.Fatalf("%s: can't encode %s: %v", c.name, f.name, err)
+			}
+			b.Run(f.name+"/"+c.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := c.dec(enc, f.new()); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(enc)), "bytes/value")
+			})
+		}
+	}
+}
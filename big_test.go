@@ -0,0 +1,172 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Amounts struct {
+	Wait  time.Duration
+	Int   big.Int
+	Float *big.Float
+	Rat   *big.Rat
+}
+
+func TestBig(t *testing.T) {
+
+	num, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	flt, _ := new(big.Float).SetPrec(200).SetString("3.14159265358979323846264338327950288")
+
+	// The accuracy of a big.Float describes the last
+	// rounding, rather than the value, so it is not
+	// encoded, and decodes as exact.
+
+	flt.SetMode(flt.Mode())
+	rat := big.NewRat(-22, 7)
+
+	Convey("time.Duration will encode and decode", t, func() {
+		var tmp time.Duration
+		var val = 90 * time.Second
+		var bit = []byte{cAlt, cAltDur, cInt64, 0, 0, 0, 20, 244, 107, 4, 0}
+		var enc = Encode(val)
+		var dec = Decode(bit)
+		DecodeInto(bit, &tmp)
+		So(enc, ShouldResemble, bit)
+		So(tmp, ShouldEqual, val)
+		So(dec, ShouldEqual, val)
+	})
+
+	Convey("time.Duration decodes from an integer", t, func() {
+		var tmp time.Duration
+		DecodeInto(Encode(int64(time.Second)), &tmp)
+		So(tmp, ShouldEqual, time.Second)
+	})
+
+	Convey("*big.Int will encode and decode", t, func() {
+		var tmp big.Int
+		var enc = Encode(num)
+		So(enc[:2], ShouldResemble, []byte{cAlt, cAltInt})
+		DecodeInto(enc, &tmp)
+		So(tmp.Cmp(num), ShouldEqual, 0)
+		So(Decode(enc), ShouldResemble, num)
+	})
+
+	Convey("*big.Int decodes from an integer or text", t, func() {
+		var one, two, six big.Int
+		DecodeInto(Encode(-5), &one)
+		DecodeInto(Encode(uint64(1<<63)), &two)
+		DecodeInto(Encode([]byte("600")), &six)
+		So(one.Int64(), ShouldEqual, -5)
+		So(two.Uint64(), ShouldEqual, uint64(1<<63))
+		So(six.Int64(), ShouldEqual, 600)
+	})
+
+	Convey("*big.Float will encode and decode", t, func() {
+		var tmp big.Float
+		var enc = Encode(flt)
+		So(enc[:2], ShouldResemble, []byte{cAlt, cAltFlt})
+		DecodeInto(enc, &tmp)
+		So(tmp.Cmp(flt), ShouldEqual, 0)
+		So(tmp.Prec(), ShouldEqual, 200)
+		So(Decode(enc), ShouldResemble, flt)
+	})
+
+	Convey("*big.Rat will encode and decode", t, func() {
+		var tmp big.Rat
+		var enc = Encode(rat)
+		So(enc[:2], ShouldResemble, []byte{cAlt, cAltRat})
+		DecodeInto(enc, &tmp)
+		So(tmp.Cmp(rat), ShouldEqual, 0)
+		So(Decode(enc), ShouldResemble, rat)
+	})
+
+	Convey("Big numbers are encoded with their sign and magnitude", t, func() {
+		So(Encode(big.NewInt(-258)), ShouldResemble, []byte{cAlt, cAltInt, cFixBin + 0x03, 1, 1, 2})
+		So(Encode(big.NewRat(3, 4)), ShouldResemble, []byte{cAlt, cAltRat, cFixBin + 0x07, 0, 0, 0, 0, 1, 3, 4})
+		So(Encode(big.NewFloat(-6)), ShouldResemble, []byte{cAlt, cAltFlt, cBin8, 16, 0, 0, 0, 0, 53, 1, 1, 0, 0, 0, 0, 0, 0, 0, 1, 3})
+	})
+
+	Convey("Zero and infinite big floats will encode and decode", t, func() {
+		for _, v := range []*big.Float{
+			new(big.Float),
+			new(big.Float).SetPrec(10).Neg(new(big.Float)),
+			new(big.Float).SetInf(true),
+			new(big.Float).SetMode(big.ToZero).SetFloat64(0.1),
+		} {
+			var tmp big.Float
+			DecodeInto(Encode(v), &tmp)
+			So(tmp.Cmp(v), ShouldEqual, 0)
+			So(tmp.Signbit(), ShouldEqual, v.Signbit())
+			So(tmp.Prec(), ShouldEqual, v.Prec())
+			So(tmp.Mode(), ShouldEqual, v.Mode())
+		}
+	})
+
+	Convey("Invalid big numbers will fail to decode", t, func() {
+		for _, bit := range [][]byte{
+			{cAlt, cAltInt, cFixBin},
+			{cAlt, cAltInt, cFixBin + 0x02, 2, 1},
+			{cAlt, cAltRat, cFixBin + 0x06, 0, 0, 0, 0, 1, 3},
+			{cAlt, cAltRat, cFixBin + 0x06, 0, 0, 0, 9, 1, 3},
+			{cAlt, cAltFlt, cFixBin + 0x07, 9, 0, 0, 0, 53, 0, 0},
+			{cAlt, cAltFlt, cBin8, 16, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1, 3},
+		} {
+			var tmp interface{}
+			So(NewDecoderBytes(bit).Decode(&tmp), ShouldNotBeNil)
+		}
+	})
+
+	Convey("Nil big numbers encode as nil", t, func() {
+		So(Encode((*big.Int)(nil)), ShouldResemble, []byte{cNil})
+		So(Decode(Encode(Amounts{})), ShouldResemble, map[interface{}]interface{}{"Wait": time.Duration(0), "Int": new(big.Int), "Float": nil, "Rat": nil})
+	})
+
+	Convey("Struct fields will encode and decode", t, func() {
+		var tmp Amounts
+		var val = Amounts{Wait: time.Hour, Int: *num, Float: flt, Rat: rat}
+		DecodeInto(Encode(val), &tmp)
+		So(tmp.Wait, ShouldEqual, val.Wait)
+		So(tmp.Int.Cmp(num), ShouldEqual, 0)
+		So(tmp.Float.Cmp(flt), ShouldEqual, 0)
+		So(tmp.Rat.Cmp(rat), ShouldEqual, 0)
+		So(Decode(Encode(val)), ShouldResemble, map[interface{}]interface{}{"Wait": time.Hour, "Int": num, "Float": flt, "Rat": rat})
+	})
+
+	Convey("Big numbers can be skipped", t, func() {
+		var tmp struct{ Wait time.Duration }
+		So(NewDecoderBytes(Encode(Amounts{Wait: time.Hour, Int: *num, Float: flt, Rat: rat})).Decode(&tmp), ShouldBeNil)
+		So(tmp.Wait, ShouldEqual, time.Hour)
+	})
+
+	Convey("Big numbers can be read as tokens", t, func() {
+		r := newReader()
+		r.r.ResetBytes(Encode([]interface{}{time.Second, num, flt}))
+		r.Next()
+		So(r.Peek(), ShouldEqual, TokenInt)
+		one, _ := r.Next()
+		two, _ := r.Next()
+		So(r.Peek(), ShouldEqual, TokenFloat)
+		six, _ := r.Next()
+		So(one, ShouldResemble, Token{Kind: TokenInt, Value: time.Second})
+		So(two, ShouldResemble, Token{Kind: TokenInt, Value: num})
+		So(six, ShouldResemble, Token{Kind: TokenFloat, Value: flt})
+	})
+
+}
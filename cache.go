@@ -32,38 +32,18 @@ type cache struct {
 	c  map[reflect.Type]int
 	sl sync.RWMutex
 	s  map[reflect.Type]int
-	ml sync.RWMutex
-	m  map[reflect.Type][]*field
+	pl sync.RWMutex
+	p  map[reflect.Type]*plan
 }
 
 func init() {
 	c = cache{
 		c: make(map[reflect.Type]int),
 		s: make(map[reflect.Type]int),
-		m: make(map[reflect.Type][]*field),
+		p: make(map[reflect.Type]*plan),
 	}
 }
 
-func (c *cache) Has(t reflect.Type) bool {
-	c.ml.RLock()
-	val := c.m[t] != nil
-	c.ml.RUnlock()
-	return val
-}
-
-func (c *cache) Get(t reflect.Type) []*field {
-	c.ml.RLock()
-	val := c.m[t]
-	c.ml.RUnlock()
-	return val
-}
-
-func (c *cache) Set(t reflect.Type, fls []*field) {
-	c.ml.Lock()
-	c.m[t] = fls
-	c.ml.Unlock()
-}
-
 func (c *cache) Corkable(t reflect.Type) bool {
 	c.cl.RLock()
 	switch c.c[t] {
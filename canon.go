@@ -0,0 +1,253 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/surrealdb/bump"
+)
+
+// The bits which every NaN is written with in canonical form.
+const (
+	nan32 uint32 = 0x7FC00000
+	nan64 uint64 = 0x7FF8000000000001
+)
+
+// IsCanonical reports whether src holds exactly one value, which
+// is encoded in its canonical form, as it would be encoded with
+// the Canonical option set on the Handle. The DefaultRegistry is
+// used to find any framed Selfers, whose contents must also be in
+// canonical form.
+func IsCanonical(src []byte) bool {
+	return canonical(&Handle{Canonical: true}, src) < 0
+}
+
+// canonical returns the offset of the first byte at which the
+// value in src differs from its canonical form, or -1 if src
+// holds exactly one value, in its canonical form.
+func canonical(h *Handle, src []byte) (i int) {
+	defer func() {
+		if v := recover(); v != nil {
+			i = 0
+			if e, ok := v.(*DecodeError); ok {
+				i = e.Offset
+			}
+		}
+	}()
+	var dst []byte
+	r := &Reader{h: h, r: bump.NewReaderBytes(src), z: len(src)}
+	w := &Writer{h: h, w: bump.NewWriterBytes(&dst)}
+	w.canonize(r)
+	for i = 0; i < len(src) && i < len(dst); i++ {
+		if src[i] != dst[i] {
+			return i
+		}
+	}
+	if len(src) == len(dst) {
+		return -1
+	}
+	return i
+}
+
+// canonizeAll rewrites every value in src into its canonical
+// form, which is used for the contents of framed Selfers.
+func canonizeAll(h *Handle, src []byte) (dst []byte) {
+	r := &Reader{h: h, r: bump.NewReaderBytes(src), z: len(src)}
+	w := &Writer{h: h, w: bump.NewWriterBytes(&dst)}
+	for r.n < len(src) {
+		w.canonize(r)
+	}
+	return
+}
+
+// canonizeRaw rewrites a Raw value into its canonical form,
+// failing if the Raw does not hold exactly one valid value.
+func (w *Writer) canonizeRaw(v Raw) (dst []byte) {
+	defer func() {
+		if e := recover(); e != nil {
+			panic(&EncodeError{Type: typeOf(v), Err: recovered(e)})
+		}
+	}()
+	r := &Reader{h: w.h, r: bump.NewReaderBytes(v), z: len(v)}
+	x := &Writer{h: w.h, w: bump.NewWriterBytes(&dst)}
+	if x.canonize(r); r.n != len(v) {
+		panic(noncanonical)
+	}
+	return
+}
+
+// decodeCanonical decodes the next value from the Reader once
+// it has checked that the value is in its canonical form.
+func (r *Reader) decodeCanonical(dst interface{}) error {
+	var raw Raw
+	if err := r.Decode(&raw); err != nil {
+		return err
+	}
+	o := r.n - len(raw)
+	if i := canonical(r.h, raw); i >= 0 {
+		r.e = &DecodeError{Offset: o + i, Type: typeOf(dst), Err: noncanonical}
+		return r.e
+	}
+	t := &Reader{h: r.h, r: bump.NewReaderBytes(raw), z: r.n, n: o, d: r.d}
+	r.e = t.Decode(dst)
+	return r.e
+}
+
+// canonical reports whether values are written in canonical form.
+func (w *Writer) canonical() bool {
+	return w.h != nil && w.h.Canonical
+}
+
+// canon32 returns the canonical form of a float32.
+func canon32(v float32) float32 {
+	switch {
+	case v != v:
+		return math.Float32frombits(nan32)
+	case v == 0:
+		return 0
+	}
+	return v
+}
+
+// canon64 returns the canonical form of a float64.
+func canon64(v float64) float64 {
+	switch {
+	case v != v:
+		return math.Float64frombits(nan64)
+	case v == 0:
+		return 0
+	}
+	return v
+}
+
+// canonize reads the next value from the Reader, and writes
+// it to the Writer in its canonical form. Values are decoded
+// and encoded again, apart from Corkers, which are opaque, and
+// are written with their data as it was read.
+func (w *Writer) canonize(r *Reader) {
+	b := r.peekOne()
+	switch {
+	case b == cNil, isBool(b):
+		w.writeOne(r.readOne())
+	case isInt(b):
+		var v int64
+		r.DecodeInt64(&v)
+		w.EncodeInt64(v)
+	case isUint(b):
+		var v uint64
+		r.DecodeUint64(&v)
+		w.EncodeUint64(v)
+	case b == cFloat32:
+		var v float32
+		r.DecodeFloat32(&v)
+		w.EncodeFloat32(v)
+	case b == cFloat64:
+		var v float64
+		r.DecodeFloat64(&v)
+		w.EncodeFloat64(v)
+	case b == cComplex64:
+		var v complex64
+		r.DecodeComplex64(&v)
+		w.EncodeComplex64(v)
+	case b == cComplex128:
+		var v complex128
+		r.DecodeComplex128(&v)
+		w.EncodeComplex128(v)
+	case r.peekTime(b):
+		var v time.Time
+		r.DecodeTime(&v)
+		w.EncodeTime(v)
+	case isStr(b):
+		var v string
+		r.DecodeString(&v)
+		w.EncodeString(v)
+	case isBin(b):
+		var v []byte
+		r.DecodeBytes(&v)
+		w.EncodeBytes(v)
+	case isExt(b):
+		w.canonizeExt(r)
+	case isArr(b):
+		w.canonizeArr(r)
+	case isMap(b):
+		w.canonizeMap(r)
+	case isSlf(b):
+		r.readOne()
+		v := r.registered(r.readOne())
+		r.unmarshal(v)
+		w.EncodeSelfer(v)
+	default:
+		switch e := r.peekAlt(); e {
+		case cAltDur:
+			var v time.Duration
+			r.DecodeDuration(&v)
+			w.EncodeDuration(v)
+		case cAltInt, cAltFlt, cAltRat:
+			w.EncodeAny(r.createBig(e))
+		default:
+			r.readOne()
+			panic(r.unexpected("value", nil))
+		}
+	}
+}
+
+func (w *Writer) canonizeExt(r *Reader) {
+	s := r.decodeExtLen()
+	e := r.readOne()
+	d := r.readMany(s)
+	if t, ok := r.h.registry().lookup(e); ok {
+		if _, ok := reflect.New(t).Interface().(Selfer); ok {
+			d = canonizeAll(r.h, d)
+		}
+	}
+	w.encodeExtLen(len(d))
+	w.writeOne(e)
+	w.writeMany(d)
+}
+
+func (w *Writer) canonizeArr(r *Reader) {
+	var b []byte
+	x := &Writer{h: w.h, w: bump.NewWriterBytes(&b)}
+	n, i := r.decodeArrLen(), 0
+	for ; r.more(i, n); i++ {
+		x.canonize(r)
+	}
+	w.encodeArrLen(i)
+	w.writeMany(b)
+}
+
+func (w *Writer) canonizeMap(r *Reader) {
+	var a []*sortable
+	n := r.decodeMapLen()
+	for i := 0; r.more(i, n); i++ {
+		s := &sortable{enc: []byte{}}
+		(&Writer{h: w.h, w: bump.NewWriterBytes(&s.key)}).canonize(r)
+		(&Writer{h: w.h, w: bump.NewWriterBytes(&s.enc)}).canonize(r)
+		a = append(a, s)
+	}
+	sortKeys(a)
+	w.encodeMapLen(len(a))
+	for i, s := range a {
+		if i > 0 && bytes.Equal(a[i-1].key, s.key) {
+			panic(duplicate)
+		}
+		w.writeMany(s.key)
+		w.writeMany(s.enc)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// Unordered is a Selfer which writes its map in reverse order.
+type Unordered struct {
+	M map[string]int
+}
+
+func (this *Unordered) ExtendCORK() byte {
+	return 0x08
+}
+
+func (this *Unordered) MarshalCORK(w *Writer) (err error) {
+	w.EncodeMapLen(len(this.M))
+	for _, k := range []string{"c", "b", "a"} {
+		if v, ok := this.M[k]; ok {
+			w.EncodeString(k)
+			w.EncodeInt(v)
+		}
+	}
+	return
+}
+
+func (this *Unordered) UnmarshalCORK(r *Reader) (err error) {
+	return r.Decode(&this.M)
+}
+
+// Scribbled is a Selfer which writes its bytes as they are.
+type Scribbled struct {
+	B []byte
+}
+
+func (this *Scribbled) ExtendCORK() byte {
+	return 0x09
+}
+
+func (this *Scribbled) MarshalCORK(w *Writer) (err error) {
+	for _, b := range this.B {
+		w.EncodeByte(b)
+	}
+	return
+}
+
+func (this *Scribbled) UnmarshalCORK(r *Reader) (err error) {
+	return
+}
+
+type Document struct {
+	Name  string
+	Age   int
+	Tags  []string
+	Score float64
+	Key   int `cork:"key,key=3"`
+}
+
+func init() {
+	Register(&Unordered{})
+}
+
+func TestCanonical(t *testing.T) {
+
+	h := &Handle{Canonical: true}
+
+	enc := func(src interface{}) (dst []byte) {
+		if err := NewEncoderBytes(&dst).Options(h).Encode(src); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	Convey("Equal values will encode to the same bytes", t, func() {
+		doc := Document{Name: "Tobie", Age: 18, Tags: []string{"a", "b"}, Score: 1.5, Key: 7}
+		one := enc(doc)
+		So(enc(&doc), ShouldResemble, one)
+		So(enc(map[interface{}]interface{}{"Tags": []string{"a", "b"}, "Name": "Tobie", "Score": 1.5, "Age": 18, 3: 7}), ShouldResemble, one)
+		So(enc(map[interface{}]interface{}{"Score": 1.5, 3: 7, "Age": int64(18), "Name": "Tobie", "Tags": []interface{}{"a", "b"}}), ShouldResemble, one)
+		So(IsCanonical(one), ShouldBeTrue)
+	})
+
+	Convey("Maps will be sorted by their encoded keys", t, func() {
+		val := map[string]int{"bb": 1, "a": 2, "c": 3}
+		So(enc(val), ShouldResemble, []byte{cFixMap + 3, cFixStr + 1, 'a', 2, cFixStr + 1, 'c', 3, cFixStr + 2, 'b', 'b', 1})
+		for i := 0; i < 10; i++ {
+			So(enc(map[int]interface{}{-1: nil, 1: nil, 200: nil, -200: nil}), ShouldResemble, enc(map[interface{}]interface{}{200: nil, -200: nil, 1: nil, -1: nil}))
+		}
+	})
+
+	Convey("Maps with keys which encode the same will not encode", t, func() {
+		var dst []byte
+		err := NewEncoderBytes(&dst).Options(h).Encode(map[interface{}]interface{}{1: "a", int8(1): "b"})
+		So(errors.Is(err, duplicate), ShouldBeTrue)
+	})
+
+	Convey("Integers will be written in as few bytes as possible", t, func() {
+		full := &Handle{Canonical: true, FullPrecisionInts: true}
+		var dst []byte
+		NewEncoderBytes(&dst).Options(full).Encode([]interface{}{int64(1), uint16(300)})
+		So(dst, ShouldResemble, []byte{cFixArr + 2, 1, cUint16, 1, 44})
+		So(IsCanonical(dst), ShouldBeTrue)
+	})
+
+	Convey("Floats will be written with a single NaN and zero", t, func() {
+		nan := math.Float64frombits(0x7FF0000000000123)
+		So(enc(nan), ShouldResemble, enc(math.NaN()))
+		So(enc(math.Copysign(0, -1)), ShouldResemble, enc(0.0))
+		So(enc(float32(math.Copysign(0, -1))), ShouldResemble, enc(float32(0)))
+		So(enc(complex(nan, math.Copysign(0, -1))), ShouldResemble, enc(complex(math.NaN(), 0)))
+		So(IsCanonical(Encode(nan)), ShouldBeFalse)
+		So(IsCanonical(Encode(math.Copysign(0, -1))), ShouldBeFalse)
+		So(IsCanonical(Encode(float32(math.NaN()))), ShouldBeTrue)
+	})
+
+	Convey("Selfers will be framed with their contents in canonical form", t, func() {
+		var tmp Unordered
+		val := &Unordered{M: map[string]int{"a": 1, "b": 2, "c": 3}}
+		So(IsCanonical(Encode(val)), ShouldBeFalse)
+		dst := enc(val)
+		So(dst[0], ShouldEqual, cFixExt+10)
+		So(dst[2:], ShouldResemble, []byte{cFixMap + 3, cFixStr + 1, 'a', 1, cFixStr + 1, 'b', 2, cFixStr + 1, 'c', 3})
+		So(IsCanonical(dst), ShouldBeTrue)
+		So(NewDecoderBytes(dst).Options(h).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, *val)
+	})
+
+	Convey("Selfers whose contents can not be read back will not encode", t, func() {
+		var dst []byte
+		err := NewEncoderBytes(&dst).Options(h).Encode(&Scribbled{B: []byte{cAlt}})
+		So(errors.Is(err, noncanonical), ShouldBeTrue)
+	})
+
+	Convey("Selfers which fail for other reasons will keep their error", t, func() {
+		var dst []byte
+		err := NewEncoderBytes(&dst).Options(h).Encode(&Scribbled{B: []byte{cFixMap + 2, 1, cNil, 1, cNil}})
+		So(errors.Is(err, duplicate), ShouldBeTrue)
+		So(errors.Is(err, noncanonical), ShouldBeFalse)
+	})
+
+	Convey("Raw values will be rewritten into canonical form", t, func() {
+		raw := Raw{cFixMap + 2, cFixStr + 1, 'b', cInt16, 0, 1, cFixStr + 1, 'a', 2}
+		one := enc(raw)
+		So(one, ShouldResemble, []byte{cFixMap + 2, cFixStr + 1, 'a', 2, cFixStr + 1, 'b', 1})
+		So(IsCanonical(one), ShouldBeTrue)
+		So(IsCanonical(enc([]interface{}{raw, raw})), ShouldBeTrue)
+	})
+
+	Convey("Raw values which do not hold exactly one value will not encode", t, func() {
+		var one, two []byte
+		So(NewEncoderBytes(&one).Options(h).Encode(Raw{cAlt, cAltBrk}), ShouldNotBeNil)
+		So(errors.Is(NewEncoderBytes(&two).Options(h).Encode(Raw{cNil, cNil}), noncanonical), ShouldBeTrue)
+	})
+
+	Convey("Structs as arrays will keep the order of their fields", t, func() {
+		var dst []byte
+		NewEncoderBytes(&dst).Options(&Handle{Canonical: true, StructAsArray: true}).Encode(Document{Name: "a"})
+		So(dst[:3], ShouldResemble, []byte{cFixArr + 5, cFixStr + 1, 'a'})
+		So(IsCanonical(dst), ShouldBeTrue)
+	})
+
+	Convey("Indefinite-length values will not encode", t, func() {
+		var dst []byte
+		e := NewEncoderBytes(&dst).Options(h)
+		So(errors.Is(e.BeginArray(), unbounded), ShouldBeTrue)
+		So(errors.Is(e.BeginMap(), unbounded), ShouldBeTrue)
+		So(errors.Is(e.EncodeToken(Token{Kind: TokenSlfBegin, Ext: 0x08}), unbounded), ShouldBeTrue)
+	})
+
+	Convey("Values which are not in canonical form will be detected", t, func() {
+		var dst []byte
+		e := NewEncoderBytes(&dst)
+		e.BeginArray()
+		e.EndArray()
+		So(IsCanonical(nil), ShouldBeFalse)
+		So(IsCanonical(dst), ShouldBeFalse)
+		So(IsCanonical([]byte{cInt16, 0, 1}), ShouldBeFalse)
+		So(IsCanonical([]byte{cUint8, 1}), ShouldBeFalse)
+		So(IsCanonical([]byte{cStr8, 1, 'a'}), ShouldBeFalse)
+		So(IsCanonical([]byte{cArr, 1, cNil}), ShouldBeFalse)
+		So(IsCanonical([]byte{cFixMap + 2, cFixStr + 1, 'b', 1, cFixStr + 1, 'a', 2}), ShouldBeFalse)
+		So(IsCanonical([]byte{cFixMap + 2, cFixStr + 1, 'a', 1, cFixStr + 1, 'a', 2}), ShouldBeFalse)
+		So(IsCanonical([]byte{cNil, cNil}), ShouldBeFalse)
+		So(IsCanonical([]byte{cAlt, cAltBrk}), ShouldBeFalse)
+		So(IsCanonical(Encode(&Selfed{Name: "test"})), ShouldBeFalse)
+		So(IsCanonical([]byte{cFixMap + 2, cFixStr + 1, 'a', 1, cFixStr + 1, 'b', 2}), ShouldBeTrue)
+		So(IsCanonical([]byte{cFixExt + 2, 0x01, 0xFF, 0x00}), ShouldBeTrue)
+	})
+
+	Convey("Times will be written in their compact form when possible", t, func() {
+		utc := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+		alt := []byte{cAlt, cAltTime}
+		alt = append(alt, Encode(utc)[1:]...)
+		So(IsCanonical(Encode(utc)), ShouldBeTrue)
+		So(IsCanonical(Encode(utc.In(time.FixedZone("X", 3600)))), ShouldBeTrue)
+		So(IsCanonical(Encode(time.Time{})), ShouldBeTrue)
+		So(IsCanonical(alt), ShouldBeFalse)
+	})
+
+	Convey("Canonical decoders will reject values which are not in canonical form", t, func() {
+		var tmp map[string]int
+		var err *DecodeError
+		bit := []byte{cNil, cFixMap + 2, cFixStr + 1, 'a', 1, cFixStr + 1, 'b', cInt16, 0, 2}
+		dec := NewDecoderBytes(bit).Options(h)
+		So(dec.Decode(new(interface{})), ShouldBeNil)
+		So(errors.As(dec.Decode(&tmp), &err), ShouldBeTrue)
+		So(errors.Is(err, noncanonical), ShouldBeTrue)
+		So(err.Offset, ShouldEqual, 7)
+		So(NewDecoderBytes(bit[1:]).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, map[string]int{"a": 1, "b": 2})
+	})
+
+	Convey("Canonical decoders will report errors at their offset in the stream", t, func() {
+		var tmp map[string]string
+		var err *DecodeError
+		bit := []byte{cNil, cFixMap + 1, cFixStr + 1, 'a', cTrue}
+		dec := NewDecoderBytes(bit).Options(h)
+		So(dec.Decode(new(interface{})), ShouldBeNil)
+		So(errors.As(dec.Decode(&tmp), &err), ShouldBeTrue)
+		So(errors.Is(err, fail), ShouldBeTrue)
+		So(err.Path, ShouldEqual, `["a"]`)
+		So(err.Offset, ShouldEqual, 4)
+	})
+
+	Convey("Canonical output will decode with any Handle", t, func() {
+		var tmp Document
+		doc := Document{Name: "Tobie", Age: 18, Tags: []string{"a"}, Score: 1.5, Key: 7}
+		So(bytes.Equal(Encode(doc), enc(doc)), ShouldBeFalse)
+		So(NewDecoderBytes(enc(doc)).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, doc)
+	})
+
+}
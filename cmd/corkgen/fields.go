@@ -0,0 +1,225 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// field mirrors the field type in the cork package, but
+// is built from go/types, and also holds the path of
+// struct fields which leads to it through any embedded
+// structs, so that the code to access it can be written.
+type field struct {
+	omit bool
+	keyd bool
+	key  int
+	indx []int
+	path []*types.Var
+	name string
+	show string
+}
+
+func (f *field) Name() string {
+	if len(f.show) > 0 {
+		return f.show
+	}
+	return f.name
+}
+
+func (f *field) kind() types.Type {
+	return f.path[len(f.path)-1].Type()
+}
+
+func (f *field) expr() string {
+	return f.exprTo(len(f.path))
+}
+
+func (f *field) exprTo(n int) string {
+	out := "x"
+	for _, v := range f.path[:n] {
+		out += "." + v.Name()
+	}
+	return out
+}
+
+// fields returns the fields of a struct type in the same
+// order, and with the same promotion rules for embedded
+// structs, as the newFields function in the cork package.
+func (g *generator) fields(t types.Type) []*field {
+
+	type embed struct {
+		kind types.Type
+		indx []int
+		path []*types.Var
+	}
+
+	var all []*field
+
+	seen := map[string]bool{}
+
+	for next := []embed{{kind: t}}; len(next) > 0; {
+
+		curr := next
+		next = nil
+
+		for _, e := range curr {
+			if seen[e.kind.String()] {
+				continue
+			}
+			s := e.kind.Underlying().(*types.Struct)
+			for i := 0; i < s.NumFields(); i++ {
+				v := s.Field(i)
+				tag := reflect.StructTag(s.Tag(i)).Get("cork")
+				x := append(append([]int{}, e.indx...), i)
+				p := append(append([]*types.Var{}, e.path...), v)
+				if k := g.embedded(v, tag); k != nil {
+					next = append(next, embed{kind: k, indx: x, path: p})
+					continue
+				}
+				if f := newField(v, tag); f != nil {
+					f.indx, f.path = x, p
+					all = append(all, f)
+				}
+			}
+		}
+
+		for _, e := range curr {
+			seen[e.kind.String()] = true
+		}
+
+	}
+
+	fls := make([]*field, 0, len(all))
+
+	for _, f := range all {
+		if dominant(f, all) {
+			fls = append(fls, f)
+		}
+	}
+
+	sort.SliceStable(fls, func(i, j int) bool {
+		x, y := fls[i].indx, fls[j].indx
+		for k := 0; k < len(x) && k < len(y); k++ {
+			if x[k] != y[k] {
+				return x[k] < y[k]
+			}
+		}
+		return len(x) < len(y)
+	})
+
+	return fls
+
+}
+
+// dominant checks whether a field is the one which should
+// be used out of all of the fields with the same name.
+func dominant(f *field, all []*field) bool {
+	for _, o := range all {
+		if o == f || o.Name() != f.Name() {
+			continue
+		}
+		switch {
+		case len(o.indx) < len(f.indx):
+			return false
+		case len(o.indx) > len(f.indx):
+			continue
+		case len(f.show) == 0 || len(o.show) > 0:
+			return false
+		}
+	}
+	return true
+}
+
+// embedded returns the struct type of an embedded field
+// whose fields should be promoted, or nil if they should
+// not be, following the same rules as the cork package.
+func (g *generator) embedded(v *types.Var, tag string) types.Type {
+
+	if !v.Embedded() {
+		return nil
+	}
+
+	if tag == "-" || strings.Split(tag, ",")[0] != "" {
+		return nil
+	}
+
+	t := v.Type()
+
+	if p, ok := t.(*types.Pointer); ok {
+		// Unexported pointers can't be allocated
+		if !v.Exported() {
+			return nil
+		}
+		t = p.Elem()
+	}
+
+	if _, ok := t.Underlying().(*types.Struct); !ok || native(t) != "" {
+		return nil
+	}
+
+	if g.selfer(t) || g.corker(t) {
+		return nil
+	}
+
+	return t
+
+}
+
+func newField(v *types.Var, tag string) *field {
+
+	// Field is private
+	if !v.Exported() {
+		return nil
+	}
+
+	// Field not supported
+	switch v.Type().Underlying().(type) {
+	case *types.Chan:
+		return nil
+	case *types.Signature:
+		return nil
+	}
+
+	// Field is ignored
+	if tag == "-" {
+		return nil
+	}
+
+	opts := strings.Split(tag, ",")
+
+	f := &field{
+		name: v.Name(),
+		show: opts[0],
+	}
+
+	for _, o := range opts[1:] {
+		switch {
+		case o == "omitempty":
+			f.omit = true
+		case strings.HasPrefix(o, "key="):
+			if k, err := strconv.Atoi(o[4:]); err == nil && k >= 0 {
+				f.keyd, f.key = true, k
+			}
+		}
+	}
+
+	return f
+
+}
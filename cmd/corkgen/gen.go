@@ -0,0 +1,382 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const corkPath = "github.com/surrealdb/cork"
+
+const directive = "//cork:ext "
+
+type target struct {
+	kind *types.Named
+	ext  byte
+}
+
+type generator struct {
+	buf  bytes.Buffer
+	pkg  *types.Package
+	imps map[string]string
+	gens map[*types.Named]bool
+}
+
+// generate parses and type checks the package in the directory,
+// and returns the formatted source of the generated methods for
+// each of the struct types which have a cork:ext directive.
+func generate(dir, output string) ([]byte, error) {
+
+	fset := token.NewFileSet()
+
+	// The output file is left out, so that an out
+	// of date version of it does not stop the rest
+	// of the package from being type checked.
+
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != filepath.Base(output)
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	var name string
+	var files []*ast.File
+
+	for n, p := range pkgs {
+		name = n
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return fset.File(files[i].Pos()).Name() < fset.File(files[j].Pos()).Name()
+	})
+
+	// Type errors are ignored, as any code which
+	// uses the methods which are yet to be generated
+	// will not type check until they exist.
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+
+	pkg, _ := conf.Check(name, fset, files, nil)
+
+	g := &generator{
+		pkg:  pkg,
+		imps: map[string]string{corkPath: "cork"},
+		gens: map[*types.Named]bool{},
+	}
+
+	var tgts []target
+
+	for _, f := range files {
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gd.Specs {
+				ts := s.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				ext, ok, err := extension(doc)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", ts.Name.Name, err)
+				}
+				if !ok {
+					continue
+				}
+				kind, _ := pkg.Scope().Lookup(ts.Name.Name).Type().(*types.Named)
+				if kind == nil {
+					return nil, fmt.Errorf("%s: can't find type", ts.Name.Name)
+				}
+				if _, ok := kind.Underlying().(*types.Struct); !ok {
+					return nil, fmt.Errorf("%s: is not a struct", ts.Name.Name)
+				}
+				g.gens[kind] = true
+				tgts = append(tgts, target{kind: kind, ext: ext})
+			}
+		}
+	}
+
+	if len(tgts) == 0 {
+		return nil, fmt.Errorf("no types with a cork:ext directive in %s", dir)
+	}
+
+	for _, t := range tgts {
+		g.generate(t)
+	}
+
+	return g.source(name, tgts)
+
+}
+
+// extension returns the extension type byte which is given
+// by a cork:ext directive in the doc comment of a type.
+func extension(doc *ast.CommentGroup) (byte, bool, error) {
+	if doc == nil {
+		return 0, false, nil
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, directive) {
+			v, err := strconv.ParseUint(strings.TrimSpace(c.Text[len(directive):]), 0, 8)
+			if err != nil {
+				return 0, false, fmt.Errorf("invalid extension type %q", c.Text[len(directive):])
+			}
+			return byte(v), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// source returns the formatted source of the generated file,
+// including the imports and the registration of each type.
+func (g *generator) source(name string, tgts []target) ([]byte, error) {
+
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "// Code generated by corkgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", name)
+
+	var paths []string
+	for p := range g.imps {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	// Standard library packages are imported
+	// first, followed by any other packages,
+	// in the same way as goimports does.
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		return !strings.Contains(paths[i], ".") && strings.Contains(paths[j], ".")
+	})
+
+	fmt.Fprintf(&out, "import (\n")
+	for i, p := range paths {
+		if i > 0 && strings.Contains(p, ".") && !strings.Contains(paths[i-1], ".") {
+			fmt.Fprintf(&out, "\n")
+		}
+		if n := g.imps[p]; n != filepath.Base(p) {
+			fmt.Fprintf(&out, "%s %q\n", n, p)
+		} else {
+			fmt.Fprintf(&out, "%q\n", p)
+		}
+	}
+	fmt.Fprintf(&out, ")\n\n")
+
+	fmt.Fprintf(&out, "func init() {\n")
+	for _, t := range tgts {
+		fmt.Fprintf(&out, "cork.Register(&%s{})\n", t.kind.Obj().Name())
+	}
+	fmt.Fprintf(&out, "}\n")
+
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("can't format generated code: %v", err)
+	}
+
+	return src, nil
+
+}
+
+// generate writes the Selfer methods for a single type.
+func (g *generator) generate(t target) {
+
+	name := t.kind.Obj().Name()
+	fls := g.fields(t.kind)
+
+	g.printf("\n// ExtendCORK returns the extension type of %s.\n", name)
+	g.printf("func (x *%s) ExtendCORK() byte {\n", name)
+	g.printf("return 0x%02X\n", t.ext)
+	g.printf("}\n")
+
+	g.printf("\n// GeneratedCORK marks the methods of %s as generated.\n", name)
+	g.printf("func (x *%s) GeneratedCORK() {}\n", name)
+
+	// The fields are written as a map, in the same
+	// way as for a struct encoded using reflection,
+	// leaving out any empty fields with omitempty,
+	// and any fields behind nil embedded pointers.
+
+	g.printf("\n// MarshalCORK encodes %s to the Writer.\n", name)
+	g.printf("func (x *%s) MarshalCORK(w *cork.Writer) error {\n", name)
+
+	size := 0
+	for _, f := range fls {
+		if len(g.conds(f)) == 0 {
+			size++
+		}
+	}
+
+	g.printf("n := %d\n", size)
+	for _, f := range fls {
+		if c := g.conds(f); len(c) > 0 {
+			g.printf("if %s {\nn++\n}\n", strings.Join(c, " && "))
+		}
+	}
+	g.printf("w.EncodeMapLen(n)\n")
+
+	for _, f := range fls {
+		c := g.conds(f)
+		if len(c) > 0 {
+			g.printf("if %s {\n", strings.Join(c, " && "))
+		}
+		if f.keyd {
+			g.printf("w.EncodeKey(%d)\n", f.key)
+		} else {
+			g.printf("w.EncodeString(%q)\n", f.Name())
+		}
+		g.printf("%s\n", g.enc(f.kind(), f.expr()))
+		if len(c) > 0 {
+			g.printf("}\n")
+		}
+	}
+
+	g.printf("return w.Err()\n")
+	g.printf("}\n")
+
+	// The fields can be decoded from a map keyed by
+	// name or by integer key, or from an array of
+	// values if the struct was encoded as an array.
+
+	keyd := false
+	for _, f := range fls {
+		keyd = keyd || f.keyd
+	}
+
+	g.printf("\n// UnmarshalCORK decodes %s from the Reader.\n", name)
+	g.printf("func (x *%s) UnmarshalCORK(r *cork.Reader) error {\n", name)
+
+	g.printf("if r.Peek() == cork.TokenArrBegin {\n")
+	g.printf("n := r.DecodeArrLen()\n")
+	g.printf("for i := 0; r.More(i, n); i++ {\n")
+	g.printf("switch i {\n")
+	for i, f := range fls {
+		g.printf("case %d:\n", i)
+		g.printf("%s\n", g.decField(f))
+	}
+	g.printf("default:\nr.Skip()\n")
+	g.printf("}\n")
+	g.printf("}\n")
+	g.printf("return nil\n")
+	g.printf("}\n")
+
+	g.printf("n := r.DecodeMapLen()\n")
+	g.printf("for i := 0; r.More(i, n); i++ {\n")
+	switch {
+	case len(fls) == 0:
+		g.printf("r.DecodeKey()\n")
+		g.printf("switch {\n")
+	case keyd:
+		g.printf("switch k, key := r.DecodeKey(); {\n")
+	default:
+		g.printf("switch k, _ := r.DecodeKey(); {\n")
+	}
+	for _, f := range fls {
+		if f.keyd {
+			g.printf("case key == %d || k == %q:\n", f.key, f.Name())
+		} else {
+			g.printf("case k == %q:\n", f.Name())
+		}
+		g.printf("%s\n", g.decField(f))
+	}
+	g.printf("default:\nr.Skip()\n")
+	g.printf("}\n")
+	g.printf("}\n")
+
+	g.printf("return nil\n")
+	g.printf("}\n")
+
+}
+
+// conds returns the conditions which must hold for a field
+// to be encoded, which are that any embedded pointers on the
+// way to it are not nil, and that it is not empty if it has
+// the omitempty option.
+func (g *generator) conds(f *field) (c []string) {
+	for i := 0; i < len(f.path)-1; i++ {
+		if _, ok := f.path[i].Type().(*types.Pointer); ok {
+			c = append(c, f.exprTo(i+1)+" != nil")
+		}
+	}
+	if f.omit {
+		if e := empty(f.kind(), f.expr()); e != "" {
+			c = append(c, "!("+e+")")
+		}
+	}
+	return
+}
+
+// decField returns the code which decodes a single field,
+// allocating any nil embedded pointers on the way to it. When
+// the field is decoded only if it is not nil, the pointers are
+// allocated within that check, so that a nil value leaves them
+// untouched.
+func (g *generator) decField(f *field) string {
+	var out []string
+	for i := 0; i < len(f.path)-1; i++ {
+		if p, ok := f.path[i].Type().(*types.Pointer); ok {
+			e := f.exprTo(i + 1)
+			out = append(out, fmt.Sprintf("if %s == nil {\n%s = new(%s)\n}", e, e, g.typeName(p.Elem())))
+		}
+	}
+	dec := g.dec(f.kind(), f.expr())
+	if len(out) == 0 {
+		return dec
+	}
+	if strings.HasPrefix(dec, nonil) {
+		return nonil + strings.Join(out, "\n") + "\n" + strings.TrimPrefix(dec, nonil)
+	}
+	return strings.Join(append(out, dec), "\n")
+}
+
+func (g *generator) typeName(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imps[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
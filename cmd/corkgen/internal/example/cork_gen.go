@@ -0,0 +1,333 @@
+// Code generated by corkgen. DO NOT EDIT.
+
+package example
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/surrealdb/cork"
+)
+
+func init() {
+	cork.Register(&Person{})
+	cork.Register(&Address{})
+}
+
+// ExtendCORK returns the extension type of Person.
+func (x *Person) ExtendCORK() byte {
+	return 0x10
+}
+
+// GeneratedCORK marks the methods of Person as generated.
+func (x *Person) GeneratedCORK() {}
+
+// MarshalCORK encodes Person to the Writer.
+func (x *Person) MarshalCORK(w *cork.Writer) error {
+	n := 16
+	if !(x.Age == 0) {
+		n++
+	}
+	if !(x.Email == "") {
+		n++
+	}
+	if !(x.Meta.Note == "") {
+		n++
+	}
+	if x.Audit != nil {
+		n++
+	}
+	w.EncodeMapLen(n)
+	w.EncodeString("name")
+	w.EncodeString(x.Name)
+	if !(x.Age == 0) {
+		w.EncodeKey(1)
+		w.EncodeInt(x.Age)
+	}
+	if !(x.Email == "") {
+		w.EncodeString("Email")
+		w.EncodeString(x.Email)
+	}
+	w.EncodeString("Tags")
+	w.EncodeAny(x.Tags)
+	w.EncodeString("Attrs")
+	w.EncodeAny(x.Attrs)
+	w.EncodeString("Data")
+	w.EncodeBytes(x.Data)
+	w.EncodeString("Born")
+	w.EncodeTime(x.Born)
+	w.EncodeString("Timeout")
+	w.EncodeDuration(x.Timeout)
+	w.EncodeString("Amount")
+	w.EncodeBigInt(x.Amount)
+	w.EncodeString("Ratio")
+	w.EncodeBigRat(&x.Ratio)
+	w.EncodeString("Level")
+	w.EncodeUint8(uint8(x.Level))
+	w.EncodeString("Score")
+	w.EncodeFloat64(x.Score)
+	w.EncodeString("Friend")
+	if x.Friend == nil {
+		w.EncodeNil()
+	} else {
+		w.EncodeSelfer(x.Friend)
+	}
+	w.EncodeString("Address")
+	if err := x.Address.MarshalCORK(w); err != nil {
+		return err
+	}
+	w.EncodeString("Extra")
+	w.EncodeAny(x.Extra)
+	w.EncodeString("Nums")
+	w.EncodeReflect(reflect.ValueOf(x.Nums))
+	w.EncodeString("Other")
+	w.EncodeReflect(reflect.ValueOf(x.Other))
+	w.EncodeString("Created")
+	w.EncodeInt64(x.Meta.Created)
+	if !(x.Meta.Note == "") {
+		w.EncodeString("note")
+		w.EncodeString(x.Meta.Note)
+	}
+	if x.Audit != nil {
+		w.EncodeString("By")
+		w.EncodeString(x.Audit.By)
+	}
+	return w.Err()
+}
+
+// UnmarshalCORK decodes Person from the Reader.
+func (x *Person) UnmarshalCORK(r *cork.Reader) error {
+	if r.Peek() == cork.TokenArrBegin {
+		n := r.DecodeArrLen()
+		for i := 0; r.More(i, n); i++ {
+			switch i {
+			case 0:
+				if !r.DecodeNil() {
+					r.DecodeString(&x.Name)
+				}
+			case 1:
+				if !r.DecodeNil() {
+					r.DecodeInt(&x.Age)
+				}
+			case 2:
+				if !r.DecodeNil() {
+					r.DecodeString(&x.Email)
+				}
+			case 3:
+				if !r.DecodeNil() {
+					r.DecodeAny(&x.Tags)
+				}
+			case 4:
+				if !r.DecodeNil() {
+					r.DecodeAny(&x.Attrs)
+				}
+			case 5:
+				if !r.DecodeNil() {
+					r.DecodeBytes(&x.Data)
+				}
+			case 6:
+				if !r.DecodeNil() {
+					r.DecodeTime(&x.Born)
+				}
+			case 7:
+				if !r.DecodeNil() {
+					r.DecodeDuration(&x.Timeout)
+				}
+			case 8:
+				if !r.DecodeNil() {
+					x.Amount = new(big.Int)
+					r.DecodeBigInt(x.Amount)
+				}
+			case 9:
+				if !r.DecodeNil() {
+					r.DecodeBigRat(&x.Ratio)
+				}
+			case 10:
+				if !r.DecodeNil() {
+					r.DecodeUint8((*uint8)(&x.Level))
+				}
+			case 11:
+				if !r.DecodeNil() {
+					r.DecodeFloat64(&x.Score)
+				}
+			case 12:
+				if !r.DecodeNil() {
+					x.Friend = new(Person)
+					r.DecodeSelfer(x.Friend)
+				}
+			case 13:
+				if !r.DecodeNil() {
+					r.DecodeSelfer(&x.Address)
+				}
+			case 14:
+				r.DecodeReflect(reflect.ValueOf(&x.Extra))
+			case 15:
+				r.DecodeReflect(reflect.ValueOf(&x.Nums))
+			case 16:
+				r.DecodeReflect(reflect.ValueOf(&x.Other))
+			case 17:
+				if !r.DecodeNil() {
+					r.DecodeInt64(&x.Meta.Created)
+				}
+			case 18:
+				if !r.DecodeNil() {
+					r.DecodeString(&x.Meta.Note)
+				}
+			case 19:
+				if !r.DecodeNil() {
+					if x.Audit == nil {
+						x.Audit = new(Audit)
+					}
+					r.DecodeString(&x.Audit.By)
+				}
+			default:
+				r.Skip()
+			}
+		}
+		return nil
+	}
+	n := r.DecodeMapLen()
+	for i := 0; r.More(i, n); i++ {
+		switch k, key := r.DecodeKey(); {
+		case k == "name":
+			if !r.DecodeNil() {
+				r.DecodeString(&x.Name)
+			}
+		case key == 1 || k == "age":
+			if !r.DecodeNil() {
+				r.DecodeInt(&x.Age)
+			}
+		case k == "Email":
+			if !r.DecodeNil() {
+				r.DecodeString(&x.Email)
+			}
+		case k == "Tags":
+			if !r.DecodeNil() {
+				r.DecodeAny(&x.Tags)
+			}
+		case k == "Attrs":
+			if !r.DecodeNil() {
+				r.DecodeAny(&x.Attrs)
+			}
+		case k == "Data":
+			if !r.DecodeNil() {
+				r.DecodeBytes(&x.Data)
+			}
+		case k == "Born":
+			if !r.DecodeNil() {
+				r.DecodeTime(&x.Born)
+			}
+		case k == "Timeout":
+			if !r.DecodeNil() {
+				r.DecodeDuration(&x.Timeout)
+			}
+		case k == "Amount":
+			if !r.DecodeNil() {
+				x.Amount = new(big.Int)
+				r.DecodeBigInt(x.Amount)
+			}
+		case k == "Ratio":
+			if !r.DecodeNil() {
+				r.DecodeBigRat(&x.Ratio)
+			}
+		case k == "Level":
+			if !r.DecodeNil() {
+				r.DecodeUint8((*uint8)(&x.Level))
+			}
+		case k == "Score":
+			if !r.DecodeNil() {
+				r.DecodeFloat64(&x.Score)
+			}
+		case k == "Friend":
+			if !r.DecodeNil() {
+				x.Friend = new(Person)
+				r.DecodeSelfer(x.Friend)
+			}
+		case k == "Address":
+			if !r.DecodeNil() {
+				r.DecodeSelfer(&x.Address)
+			}
+		case k == "Extra":
+			r.DecodeReflect(reflect.ValueOf(&x.Extra))
+		case k == "Nums":
+			r.DecodeReflect(reflect.ValueOf(&x.Nums))
+		case k == "Other":
+			r.DecodeReflect(reflect.ValueOf(&x.Other))
+		case k == "Created":
+			if !r.DecodeNil() {
+				r.DecodeInt64(&x.Meta.Created)
+			}
+		case k == "note":
+			if !r.DecodeNil() {
+				r.DecodeString(&x.Meta.Note)
+			}
+		case k == "By":
+			if !r.DecodeNil() {
+				if x.Audit == nil {
+					x.Audit = new(Audit)
+				}
+				r.DecodeString(&x.Audit.By)
+			}
+		default:
+			r.Skip()
+		}
+	}
+	return nil
+}
+
+// ExtendCORK returns the extension type of Address.
+func (x *Address) ExtendCORK() byte {
+	return 0x11
+}
+
+// GeneratedCORK marks the methods of Address as generated.
+func (x *Address) GeneratedCORK() {}
+
+// MarshalCORK encodes Address to the Writer.
+func (x *Address) MarshalCORK(w *cork.Writer) error {
+	n := 2
+	w.EncodeMapLen(n)
+	w.EncodeString("street")
+	w.EncodeString(x.Street)
+	w.EncodeString("City")
+	w.EncodeString(x.City)
+	return w.Err()
+}
+
+// UnmarshalCORK decodes Address from the Reader.
+func (x *Address) UnmarshalCORK(r *cork.Reader) error {
+	if r.Peek() == cork.TokenArrBegin {
+		n := r.DecodeArrLen()
+		for i := 0; r.More(i, n); i++ {
+			switch i {
+			case 0:
+				if !r.DecodeNil() {
+					r.DecodeString(&x.Street)
+				}
+			case 1:
+				if !r.DecodeNil() {
+					r.DecodeString(&x.City)
+				}
+			default:
+				r.Skip()
+			}
+		}
+		return nil
+	}
+	n := r.DecodeMapLen()
+	for i := 0; r.More(i, n); i++ {
+		switch k, _ := r.DecodeKey(); {
+		case k == "street":
+			if !r.DecodeNil() {
+				r.DecodeString(&x.Street)
+			}
+		case k == "City":
+			if !r.DecodeNil() {
+				r.DecodeString(&x.City)
+			}
+		default:
+			r.Skip()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package example holds types which are used to test
+// the methods which are generated by corkgen.
+package example
+
+import (
+	"math/big"
+	"time"
+)
+
+//go:generate go run github.com/surrealdb/cork/cmd/corkgen
+
+// Level is a named type with a basic underlying type.
+type Level uint8
+
+// Meta is embedded in Person by value.
+type Meta struct {
+	Created int64
+	Note    string `cork:"note,omitempty"`
+}
+
+// Audit is embedded in Person by pointer.
+type Audit struct {
+	By string
+}
+
+// Person uses most of the kinds of field which corkgen
+// can generate code for, including some which fall back
+// to using reflection.
+//
+//cork:ext 0x10
+type Person struct {
+	Name    string `cork:"name"`
+	Age     int    `cork:"age,omitempty,key=1"`
+	Email   string `cork:",omitempty"`
+	Tags    []string
+	Attrs   map[string]string
+	Data    []byte
+	Born    time.Time
+	Timeout time.Duration
+	Amount  *big.Int
+	Ratio   big.Rat
+	Level   Level
+	Score   float64
+	Friend  *Person
+	Address Address
+	Extra   interface{}
+	Nums    [3]int
+	Other   map[string][]int
+	Meta
+	*Audit
+	skip    string
+	Ignored string `cork:"-"`
+}
+
+// Address is used as a field of Person by value.
+//
+//cork:ext 0x11
+type Address struct {
+	Street string `cork:"street"`
+	City   string
+}
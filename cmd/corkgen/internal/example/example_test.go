@@ -0,0 +1,130 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package example
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/surrealdb/cork"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// plainPerson has the same fields as Person, but none of
+// its methods, so it is encoded and decoded by reflection.
+type plainPerson Person
+
+func person() Person {
+	tme, _ := time.Parse(time.RFC3339, "1987-06-22T08:00:00.123456789Z")
+	return Person{
+		Name:    "Tobie",
+		Age:     30,
+		Tags:    []string{"one", "two"},
+		Attrs:   map[string]string{"a": "b"},
+		Data:    []byte("data"),
+		Born:    tme,
+		Timeout: time.Minute,
+		Amount:  big.NewInt(-12345),
+		Ratio:   *big.NewRat(1, 3),
+		Level:   3,
+		Score:   1.5,
+		Friend: &Person{
+			Name:    "Jaime",
+			Tags:    []string{},
+			Attrs:   map[string]string{},
+			Data:    []byte{},
+			Born:    tme,
+			Address: Address{City: "London"},
+			Other:   map[string][]int{},
+		},
+		Address: Address{Street: "Street", City: "City"},
+		Extra:   "extra",
+		Nums:    [3]int{1, 2, 3},
+		Other:   map[string][]int{"a": {1}},
+		Meta:    Meta{Created: 100, Note: "note"},
+		Audit:   &Audit{By: "admin"},
+	}
+}
+
+func TestGenerated(t *testing.T) {
+
+	Convey("Generated methods encode the same body as reflection", t, func() {
+		val := person()
+		enc := cork.Encode(&val)
+		So(enc[2:], ShouldResemble, cork.Encode(plainPerson(val)))
+	})
+
+	Convey("Generated methods skip empty and unreachable fields", t, func() {
+		val := Person{Name: "Tobie"}
+		enc := cork.Encode(&val)
+		So(enc[2:], ShouldResemble, cork.Encode(plainPerson(val)))
+	})
+
+	Convey("Generated methods round trip", t, func() {
+		var tmp Person
+		val := person()
+		cork.DecodeInto(cork.Encode(&val), &tmp)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Generated methods decode data encoded using reflection", t, func() {
+		var tmp Person
+		val := person()
+		cork.DecodeInto(cork.Encode(plainPerson(val)), &tmp)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Generated methods decode structs encoded as arrays", t, func() {
+		var tmp Person
+		var enc []byte
+		val := person()
+		cork.NewEncoderBytes(&enc).Options(&cork.Handle{StructAsArray: true}).Encode(plainPerson(val))
+		cork.DecodeInto(enc, &tmp)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Generated methods leave embedded pointers nil when decoding nil", t, func() {
+		var tmp Person
+		var enc []byte
+		val := person()
+		val.Audit = nil
+		cork.NewEncoderBytes(&enc).Options(&cork.Handle{StructAsArray: true}).Encode(plainPerson(val))
+		cork.DecodeInto(enc, &tmp)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Reflection decodes data encoded using generated methods", t, func() {
+		var tmp plainPerson
+		val := person()
+		cork.DecodeInto(cork.Encode(&val), &tmp)
+		So(tmp, ShouldResemble, plainPerson(val))
+	})
+
+	Convey("Reflection does not decode data encoded by other types", t, func() {
+		var tmp plainPerson
+		val := Address{City: "City"}
+		So(cork.NewDecoderBytes(cork.Encode(&val)).Decode(&tmp), ShouldNotBeNil)
+	})
+
+	Convey("Generated methods skip unknown fields", t, func() {
+		var tmp Address
+		enc := cork.Encode(map[string]interface{}{"street": "Street", "Zip": []int{1}, "City": "City"})
+		cork.DecodeInto(enc, &tmp)
+		So(tmp, ShouldResemble, Address{Street: "Street", City: "City"})
+	})
+
+}
@@ -0,0 +1,88 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Corkgen generates Selfer methods for structs, so that they can be encoded
+and decoded without run-time reflection.
+
+Usage:
+
+	corkgen [-output file] [dir]
+
+Corkgen parses the Go package in the directory (or the current directory),
+and generates the ExtendCORK, MarshalCORK and UnmarshalCORK methods for each
+struct type which has a cork:ext directive in its doc comment, giving the
+extension type byte to use for the type:
+
+	//cork:ext 0x10
+	type Person struct {
+		Name string `cork:"name"`
+		Age  int    `cork:"age,omitempty,key=1"`
+	}
+
+The generated methods write the same map of fields as is written for a struct
+using reflection, honouring cork tags, omitempty, integer keys and embedded
+structs, preceded by the self-describing header of the type. Data which was
+encoded using reflection can be decoded by the generated methods, but data
+which was encoded by the generated methods can only be decoded using
+reflection by code which registers the generated type. Fields of types which
+can not be encoded directly fall back to using reflection for that field only.
+
+The types are registered with cork.Register when the package is initialised,
+which panics if another type is already registered with the same extension.
+The methods are written to cork_gen.go unless another output file is given.
+The tool is typically run using go generate:
+
+	//go:generate corkgen
+
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+
+	out := flag.String("output", "cork_gen.go", "the file to write the generated methods to")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: corkgen [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	dir := "."
+
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	src, err := generate(dir, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "corkgen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, *out), src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "corkgen: %v\n", err)
+		os.Exit(1)
+	}
+
+}
@@ -0,0 +1,41 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGenerate(t *testing.T) {
+
+	Convey("Generated code is up to date", t, func() {
+		dir := filepath.Join("internal", "example")
+		src, err := generate(dir, "cork_gen.go")
+		So(err, ShouldBeNil)
+		old, err := ioutil.ReadFile(filepath.Join(dir, "cork_gen.go"))
+		So(err, ShouldBeNil)
+		So(string(src), ShouldEqual, string(old))
+	})
+
+	Convey("Packages without directives are rejected", t, func() {
+		_, err := generate(".", "cork_gen.go")
+		So(err, ShouldNotBeNil)
+	})
+
+}
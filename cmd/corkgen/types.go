@@ -0,0 +1,299 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// basics maps each basic kind to the name of the
+// method which encodes or decodes it, which is the
+// name of the type with the first letter capitalised.
+var basics = map[types.BasicKind]string{
+	types.Bool:       "Bool",
+	types.String:     "String",
+	types.Int:        "Int",
+	types.Int8:       "Int8",
+	types.Int16:      "Int16",
+	types.Int32:      "Int32",
+	types.Int64:      "Int64",
+	types.Uint:       "Uint",
+	types.Uint8:      "Uint8",
+	types.Uint16:     "Uint16",
+	types.Uint32:     "Uint32",
+	types.Uint64:     "Uint64",
+	types.Float32:    "Float32",
+	types.Float64:    "Float64",
+	types.Complex64:  "Complex64",
+	types.Complex128: "Complex128",
+}
+
+// slices and maps are the composite types which are
+// encoded by EncodeAny and decoded by DecodeAny without
+// reflection, and in the same way as using reflection.
+var slices = map[string]bool{
+	"[]bool":            true,
+	"[]int":             true,
+	"[]int8":            true,
+	"[]int16":           true,
+	"[]int32":           true,
+	"[]int64":           true,
+	"[]uint":            true,
+	"[]uint16":          true,
+	"[]uint32":          true,
+	"[]uint64":          true,
+	"[]string":          true,
+	"[]float32":         true,
+	"[]float64":         true,
+	"[]complex64":       true,
+	"[]complex128":      true,
+	"[]time.Time":       true,
+	"map[string]int":    true,
+	"map[string]uint":   true,
+	"map[string]bool":   true,
+	"map[string]string": true,
+}
+
+func isCork(t types.Type, name string) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == corkPath && n.Obj().Name() == name
+}
+
+// natives maps the types which have their own methods on
+// the Writer and the Reader to the names of those methods.
+var natives = map[string]string{
+	"time.Time":      "Time",
+	"time.Duration":  "Duration",
+	"math/big.Int":   "BigInt",
+	"math/big.Float": "BigFloat",
+	"math/big.Rat":   "BigRat",
+}
+
+// native returns the name of the methods which encode and
+// decode the type, or nothing if the type has no methods.
+func native(t types.Type) string {
+	n, ok := t.(*types.Named)
+	if !ok || n.Obj().Pkg() == nil {
+		return ""
+	}
+	return natives[n.Obj().Pkg().Path()+"."+n.Obj().Name()]
+}
+
+// isBig checks whether the type is one of the math/big
+// types, which are encoded and decoded by pointer.
+func isBig(t types.Type) bool {
+	return strings.HasPrefix(native(t), "Big")
+}
+
+func isBytes(t types.Type) bool {
+	s, ok := t.(*types.Slice)
+	if !ok {
+		return false
+	}
+	b, ok := s.Elem().(*types.Basic)
+	return ok && b.Kind() == types.Uint8
+}
+
+// method checks whether a pointer to the type has a method
+// with the name, which takes a single cork type as its only
+// parameter, or no parameters if the cork type is empty.
+func method(t types.Type, name, param string) bool {
+	s := types.NewMethodSet(types.NewPointer(t)).Lookup(nil, name)
+	if s == nil {
+		return false
+	}
+	sig := s.Type().(*types.Signature)
+	if param == "" {
+		return sig.Params().Len() == 0
+	}
+	return sig.Params().Len() == 1 && isCork(sig.Params().At(0).Type(), param)
+}
+
+// selfer checks whether a pointer to the type is a Selfer,
+// either already or once the methods have been generated.
+func (g *generator) selfer(t types.Type) bool {
+	if n, ok := t.(*types.Named); ok && g.gens[n] {
+		return true
+	}
+	return method(t, "MarshalCORK", "Writer") && method(t, "UnmarshalCORK", "Reader")
+}
+
+// corker checks whether a pointer to the type is a Corker.
+func (g *generator) corker(t types.Type) bool {
+	return method(t, "MarshalCORK", "") && method(t, "UnmarshalCORK", "")
+}
+
+// empty returns the expression which checks whether a field
+// is empty for omitempty, or nothing if it is never empty.
+func empty(t types.Type, e string) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return e + ` == ""`
+		case u.Info()&types.IsBoolean != 0:
+			return "!" + e
+		case u.Info()&types.IsComplex != 0:
+			return ""
+		case u.Info()&types.IsNumeric != 0:
+			return e + " == 0"
+		}
+	case *types.Array, *types.Slice, *types.Map:
+		return "len(" + e + ") == 0"
+	case *types.Pointer, *types.Interface:
+		return e + " == nil"
+	}
+	return ""
+}
+
+// enc returns the code which encodes the value of a field.
+func (g *generator) enc(t types.Type, e string) string {
+
+	switch u := t.(type) {
+
+	case *types.Basic:
+		if m, ok := basics[u.Kind()]; ok {
+			return fmt.Sprintf("w.Encode%s(%s)", m, e)
+		}
+
+	case *types.Slice:
+		if isBytes(u) {
+			return fmt.Sprintf("w.EncodeBytes(%s)", e)
+		}
+		if slices[g.canon(u)] {
+			return fmt.Sprintf("w.EncodeAny(%s)", e)
+		}
+
+	case *types.Map:
+		if slices[g.canon(u)] {
+			return fmt.Sprintf("w.EncodeAny(%s)", e)
+		}
+
+	case *types.Pointer:
+		if g.selfer(u.Elem()) {
+			return fmt.Sprintf("if %s == nil {\nw.EncodeNil()\n} else {\nw.EncodeSelfer(%s)\n}", e, e)
+		}
+		if isBig(u.Elem()) {
+			return fmt.Sprintf("w.Encode%s(%s)", native(u.Elem()), e)
+		}
+
+	case *types.Named:
+		switch {
+		case isBig(u):
+			return fmt.Sprintf("w.Encode%s(&%s)", native(u), e)
+		case native(u) != "":
+			return fmt.Sprintf("w.Encode%s(%s)", native(u), e)
+		case g.gens[u]:
+			return fmt.Sprintf("if err := %s.MarshalCORK(w); err != nil {\nreturn err\n}", e)
+		case g.selfer(u) || g.corker(u):
+			break
+		default:
+			// Named types with a basic underlying type
+			// are encoded in the same way as the basic
+			// type, whatever methods they may have.
+			if b, ok := u.Underlying().(*types.Basic); ok {
+				if m, ok := basics[b.Kind()]; ok {
+					return fmt.Sprintf("w.Encode%s(%s(%s))", m, b.Name(), e)
+				}
+			}
+		}
+
+	case *types.Interface:
+		return fmt.Sprintf("w.EncodeAny(%s)", e)
+
+	}
+
+	g.imps["reflect"] = "reflect"
+
+	return fmt.Sprintf("w.EncodeReflect(reflect.ValueOf(%s))", e)
+
+}
+
+// nonil opens the check which decodes a field only if the
+// value in the stream is not nil.
+const nonil = "if !r.DecodeNil() {\n"
+
+// dec returns the code which decodes the value of a field.
+func (g *generator) dec(t types.Type, e string) string {
+
+	nilable := func(s string) string {
+		return nonil + s + "\n}"
+	}
+
+	switch u := t.(type) {
+
+	case *types.Basic:
+		if m, ok := basics[u.Kind()]; ok {
+			return nilable(fmt.Sprintf("r.Decode%s(&%s)", m, e))
+		}
+
+	case *types.Slice:
+		if isBytes(u) {
+			return nilable(fmt.Sprintf("r.DecodeBytes(&%s)", e))
+		}
+		if slices[g.canon(u)] {
+			return nilable(fmt.Sprintf("r.DecodeAny(&%s)", e))
+		}
+
+	case *types.Map:
+		if slices[g.canon(u)] {
+			return nilable(fmt.Sprintf("r.DecodeAny(&%s)", e))
+		}
+
+	case *types.Pointer:
+		if g.selfer(u.Elem()) {
+			return nilable(fmt.Sprintf("%s = new(%s)\nr.DecodeSelfer(%s)", e, g.typeName(u.Elem()), e))
+		}
+		if isBig(u.Elem()) {
+			return nilable(fmt.Sprintf("%s = new(%s)\nr.Decode%s(%s)", e, g.typeName(u.Elem()), native(u.Elem()), e))
+		}
+
+	case *types.Named:
+		switch {
+		case native(u) != "":
+			return nilable(fmt.Sprintf("r.Decode%s(&%s)", native(u), e))
+		case g.gens[u]:
+			return nilable(fmt.Sprintf("r.DecodeSelfer(&%s)", e))
+		case g.selfer(u) || g.corker(u):
+			break
+		default:
+			if b, ok := u.Underlying().(*types.Basic); ok {
+				if m, ok := basics[b.Kind()]; ok {
+					return nilable(fmt.Sprintf("r.Decode%s((*%s)(&%s))", m, b.Name(), e))
+				}
+			}
+		}
+
+	}
+
+	g.imps["reflect"] = "reflect"
+
+	return fmt.Sprintf("r.DecodeReflect(reflect.ValueOf(&%s))", e)
+
+}
+
+// canon returns the name of a type with any named types in
+// it qualified by their package path, so that it can be looked
+// up in the list of types which EncodeAny handles directly.
+func (g *generator) canon(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		return p.Path()
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Evolved struct {
+	Count int64
+	Total float64
+	Ratio float32
+	Limit uint16
+}
+
+func TestCoercion(t *testing.T) {
+
+	strict := &Handle{}
+	lossless := &Handle{Coercion: CoerceLossless}
+	lenient := &Handle{Coercion: CoerceLenient}
+
+	dec := func(h *Handle, src interface{}, dst interface{}) error {
+		return NewDecoderBytes(Encode(src)).Options(h).Decode(dst)
+	}
+
+	Convey("Numbers will not be converted by default", t, func() {
+		var i int64
+		var u uint64
+		var f float32
+		So(errors.Is(dec(strict, uint(200), &i), fail), ShouldBeTrue)
+		So(errors.Is(dec(strict, -5, &u), fail), ShouldBeTrue)
+		So(errors.Is(dec(strict, 5, &f), fail), ShouldBeTrue)
+		So(errors.Is(dec(strict, float64(0.5), &f), fail), ShouldBeTrue)
+		So(errors.Is(dec(nil, float64(0.5), &f), fail), ShouldBeTrue)
+	})
+
+	Convey("Integers will convert between signed and unsigned", t, func() {
+		var i int64
+		var u uint
+		So(dec(lossless, uint64(math.MaxInt64), &i), ShouldBeNil)
+		So(i, ShouldEqual, math.MaxInt64)
+		So(dec(lossless, int64(300), &u), ShouldBeNil)
+		So(u, ShouldEqual, 300)
+	})
+
+	Convey("Integers will narrow into smaller integers", t, func() {
+		var i int8
+		var u uint8
+		So(dec(lossless, int64(-128), &i), ShouldBeNil)
+		So(i, ShouldEqual, -128)
+		So(dec(lossless, uint32(255), &u), ShouldBeNil)
+		So(u, ShouldEqual, 255)
+	})
+
+	Convey("Integers out of range will fail to convert", t, func() {
+		var i int8
+		var u uint16
+		var e *DecodeError
+		bit := Encode(uint64(math.MaxUint64))
+		err := NewDecoderBytes(bit).Options(lenient).Decode(&i)
+		So(errors.As(err, &e), ShouldBeTrue)
+		So(e.Offset, ShouldEqual, 0)
+		So(errors.Is(err, outranged), ShouldBeTrue)
+		So(err.Error(), ShouldEqual, "cork: Number is out of range whilst decoding int8 (offset 0)")
+		So(errors.Is(dec(lenient, int16(128), &i), outranged), ShouldBeTrue)
+		So(errors.Is(dec(lenient, -1, &u), outranged), ShouldBeTrue)
+		So(errors.Is(dec(lenient, 65536, &u), outranged), ShouldBeTrue)
+		So(errors.Is(dec(lenient, float64(1e20), &i), outranged), ShouldBeTrue)
+		So(errors.Is(dec(lenient, math.NaN(), &u), outranged), ShouldBeTrue)
+	})
+
+	Convey("Integers will widen into floats when exact", t, func() {
+		var f float32
+		var g float64
+		So(dec(lossless, 1<<24, &f), ShouldBeNil)
+		So(f, ShouldEqual, 1<<24)
+		So(dec(lossless, uint64(1<<53), &g), ShouldBeNil)
+		So(g, ShouldEqual, 1<<53)
+		So(errors.Is(dec(lossless, 1<<24+1, &f), inexact), ShouldBeTrue)
+		So(errors.Is(dec(lossless, int64(math.MaxInt64), &g), inexact), ShouldBeTrue)
+		So(errors.Is(dec(lossless, uint64(math.MaxUint64), &g), inexact), ShouldBeTrue)
+	})
+
+	Convey("Floats will narrow when exact", t, func() {
+		var f float32
+		var i int
+		var u uint8
+		So(dec(lossless, float64(0.5), &f), ShouldBeNil)
+		So(f, ShouldEqual, 0.5)
+		So(dec(lossless, float64(-3), &i), ShouldBeNil)
+		So(i, ShouldEqual, -3)
+		So(dec(lossless, float32(200), &u), ShouldBeNil)
+		So(u, ShouldEqual, 200)
+		So(dec(lossless, math.Inf(1), &f), ShouldBeNil)
+		So(math.IsInf(float64(f), 1), ShouldBeTrue)
+		So(errors.Is(dec(lossless, float64(0.1), &f), inexact), ShouldBeTrue)
+		So(errors.Is(dec(lossless, float64(2.5), &i), inexact), ShouldBeTrue)
+		So(errors.Is(dec(lenient, float64(1e300), &f), outranged), ShouldBeTrue)
+	})
+
+	Convey("Inexact numbers will convert when lenient", t, func() {
+		var f float32
+		var i int
+		var u uint
+		So(dec(lenient, float64(0.1), &f), ShouldBeNil)
+		So(f, ShouldEqual, float32(0.1))
+		So(dec(lenient, float64(-2.9), &i), ShouldBeNil)
+		So(i, ShouldEqual, -2)
+		So(dec(lenient, float64(-0.5), &u), ShouldBeNil)
+		So(u, ShouldEqual, 0)
+		So(dec(lenient, 1<<24+1, &f), ShouldBeNil)
+		So(f, ShouldEqual, 1<<24)
+	})
+
+	Convey("Struct fields will convert when their types change", t, func() {
+		var tmp Evolved
+		val := map[string]interface{}{"Count": uint(7), "Total": 12, "Ratio": float64(0.25), "Limit": int8(3)}
+		So(dec(strict, val, &tmp), ShouldNotBeNil)
+		So(dec(lossless, val, &tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, Evolved{Count: 7, Total: 12, Ratio: 0.25, Limit: 3})
+	})
+
+}
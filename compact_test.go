@@ -0,0 +1,141 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Row struct {
+	ID    int    `cork:"id,key=1"`
+	Name  string `cork:",key=2"`
+	Note  string `cork:"note,omitempty,key=3"`
+	Extra string
+}
+
+type Point struct {
+	X, Y int
+	Tag  string
+}
+
+type Shape struct {
+	*Point
+	Name string
+}
+
+type Badkey struct {
+	ID int `cork:",key=id"`
+}
+
+type Dupkey struct {
+	ID   int    `cork:",key=1"`
+	Name string `cork:",key=1"`
+}
+
+func TestCompact(t *testing.T) {
+
+	arr := &Handle{StructAsArray: true}
+
+	Convey("Keyed fields will encode with integer keys", t, func() {
+		var tmp Row
+		var val = Row{ID: 5, Name: "a"}
+		var bit = []byte{cFixMap + 0x03, 1, 5, 2, cFixStr + 0x01, 'a', cFixStr + 0x05, 'E', 'x', 't', 'r', 'a', cFixStr}
+		var enc = Encode(val)
+		DecodeInto(bit, &tmp)
+		So(enc, ShouldResemble, bit)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Keyed fields will decode from named keys", t, func() {
+		var tmp Row
+		DecodeInto(Encode(map[string]interface{}{"id": 5, "Name": "a", "note": "b"}), &tmp)
+		So(tmp, ShouldResemble, Row{ID: 5, Name: "a", Note: "b"})
+	})
+
+	Convey("Unknown integer keys will be skipped", t, func() {
+		var tmp Row
+		DecodeInto(Encode(map[int]interface{}{1: 5, 9: []int{1, 2}}), &tmp)
+		So(tmp, ShouldResemble, Row{ID: 5})
+	})
+
+	Convey("Fields with invalid integer keys will fail", t, func() {
+		var buf []byte
+		var tmp Badkey
+		enc := NewEncoderBytes(&buf).Encode(Badkey{ID: 1})
+		dec := NewDecoderBytes(Encode(map[int]int{1: 1})).Decode(&tmp)
+		So(errors.Is(enc, badkey), ShouldBeTrue)
+		So(errors.Is(dec, badkey), ShouldBeTrue)
+	})
+
+	Convey("Fields with the same integer key will fail", t, func() {
+		var buf []byte
+		var tmp Dupkey
+		enc := NewEncoderBytes(&buf).Encode(Dupkey{ID: 1})
+		dec := NewDecoderBytes(Encode(map[int]int{1: 1})).Decode(&tmp)
+		So(errors.Is(enc, dupkey), ShouldBeTrue)
+		So(errors.Is(dec, dupkey), ShouldBeTrue)
+	})
+
+	Convey("Structs will encode as arrays", t, func() {
+		var tmp Point
+		var buf []byte
+		var val = Point{X: 1, Y: 2, Tag: "a"}
+		var bit = []byte{cFixArr + 0x03, 1, 2, cFixStr + 0x01, 'a'}
+		NewEncoderBytes(&buf).Options(arr).Encode(val)
+		DecodeInto(bit, &tmp)
+		So(buf, ShouldResemble, bit)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Structs as arrays will keep empty and nil fields in place", t, func() {
+		var tmp Shape
+		var buf []byte
+		var val = Shape{Name: "a"}
+		var bit = []byte{cFixArr + 0x04, cNil, cNil, cNil, cFixStr + 0x01, 'a'}
+		NewEncoderBytes(&buf).Options(arr).Encode(val)
+		DecodeInto(bit, &tmp)
+		So(buf, ShouldResemble, bit)
+		So(tmp, ShouldResemble, Shape{Point: nil, Name: "a"})
+	})
+
+	Convey("Structs as arrays will decode from shorter and longer arrays", t, func() {
+		var one, two Point
+		DecodeInto([]byte{cFixArr + 0x01, 7}, &one)
+		DecodeInto([]byte{cFixArr + 0x05, 1, 2, cFixStr + 0x01, 'a', cTrue, cFixArr + 0x01, cNil}, &two)
+		So(one, ShouldResemble, Point{X: 7})
+		So(two, ShouldResemble, Point{X: 1, Y: 2, Tag: "a"})
+	})
+
+	Convey("Structs as arrays will decode nested structs", t, func() {
+		var tmp []Point
+		var buf []byte
+		var val = []Point{{X: 1}, {Y: 2, Tag: "b"}}
+		NewEncoderBytes(&buf).Options(arr).Encode(val)
+		DecodeInto(buf, &tmp)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Structs as arrays will report the failing field", t, func() {
+		var tmp Point
+		var err *DecodeError
+		So(errors.As(NewDecoderBytes([]byte{cFixArr + 0x02, 1, cTrue}).Decode(&tmp), &err), ShouldBeTrue)
+		So(err.Path, ShouldEqual, ".Y")
+		So(err.Want, ShouldEqual, "int")
+	})
+
+}
@@ -25,6 +25,13 @@ const (
 	fixedMap = 1<<4 - 1 // 16
 )
 
+// Times within these seconds since the epoch can be
+// stored as nanoseconds since the epoch in an int64.
+const (
+	minTime = -(1 << 63) / 1000000000  // 1677-09-21
+	maxTime = (1<<63 - 1) / 1000000000 // 2262-04-11
+)
+
 const (
 	cFixInt     byte = 0x00 // -> 0x7F = 128
 	cFixStr          = 0x80 // -> 0x9F = 32
@@ -76,6 +83,22 @@ const (
 	_                = 0
 )
 
+// Alternative values are written as the cAlt byte
+// followed by one of the following sub-type bytes.
+const (
+	cAltBrk  byte = 0x00 // end of an indefinite-length array or map
+	cAltTime byte = 0x01 // time with seconds, nanoseconds, and zone
+	cAltDur  byte = 0x02 // time.Duration as an int
+	cAltInt  byte = 0x03 // big.Int as bin
+	cAltFlt  byte = 0x04 // big.Float as bin
+	cAltRat  byte = 0x05 // big.Rat as bin
+)
+
+// Raw represents the encoded bytes of a single CORK value. A Raw
+// value can be used to delay decoding of part of a stream, or to
+// pass a value through without decoding and re-encoding it.
+type Raw []byte
+
 // Corker represents an object which can encode and decode itself.
 type Corker interface {
 	ExtendCORK() byte
@@ -89,3 +112,12 @@ type Selfer interface {
 	MarshalCORK(*Writer) error
 	UnmarshalCORK(*Reader) error
 }
+
+// Generated represents a Selfer whose methods were generated by
+// corkgen, which encodes the same body as a struct which has been
+// encoded using reflection, so that it can also be decoded from a
+// struct which was encoded before the type had Selfer methods.
+type Generated interface {
+	Selfer
+	GeneratedCORK()
+}
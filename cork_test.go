@@ -219,6 +219,22 @@ func (this *Errord) UnmarshalCORK(src []byte) (err error) {
 
 // ----------------------------------------------------------------------
 
+type Failed struct{}
+
+func (this *Failed) ExtendCORK() byte {
+	return 0x07
+}
+
+func (this *Failed) MarshalCORK(w *Writer) (err error) {
+	return errors.New("Marshal error")
+}
+
+func (this *Failed) UnmarshalCORK(r *Reader) (err error) {
+	return errors.New("Unmarshal error")
+}
+
+// ----------------------------------------------------------------------
+
 type Simple struct{}
 
 func (this *Simple) ExtendCORK() byte {
@@ -547,6 +563,43 @@ func TestGeneral(t *testing.T) {
 		So(dec, ShouldResemble, oth)
 	})
 
+	Convey("every uint8 will encode and decode", t, func() {
+		for i := 0; i <= math.MaxUint8; i++ {
+			var tmp uint8
+			var ptr *uint8
+			var str struct{ V uint8 }
+			var val = uint8(i)
+			var oth interface{} = int(i)
+			if i > fixedInt {
+				oth = uint(i)
+			}
+			var enc = Encode(val)
+			DecodeInto(enc, &tmp)
+			DecodeInto(Encode(&val), &ptr)
+			DecodeInto(Encode(struct{ V uint8 }{val}), &str)
+			So(enc, ShouldResemble, Encode(uint(i)))
+			So(tmp, ShouldEqual, val)
+			So(*ptr, ShouldEqual, val)
+			So(str.V, ShouldEqual, val)
+			So(Decode(enc), ShouldEqual, oth)
+			So(Decode(Encode([]interface{}{val, true})), ShouldResemble, []interface{}{oth, true})
+		}
+	})
+
+	Convey("raw bytes are only written by EncodeByte", t, func() {
+		var tmp byte
+		var buf []byte
+		w := newWriter()
+		w.w.ResetBytes(&buf)
+		w.EncodeByte(cTrue)
+		r := newReader()
+		r.r.ResetBytes(buf)
+		r.DecodeByte(&tmp)
+		So(buf, ShouldResemble, []byte{cTrue})
+		So(tmp, ShouldEqual, cTrue)
+		So(Decode(Encode(uint8(cTrue))), ShouldEqual, uint(cTrue))
+	})
+
 	Convey("string will encode and decode", t, func() {
 		var tmp string
 		var val = "Hello"
@@ -876,6 +929,16 @@ func TestGeneral(t *testing.T) {
 		So(der, ShouldNotBeNil)
 	})
 
+	Convey("*Failed will not encode and decode", t, func() {
+		var tmp Failed
+		var val = &Failed{}
+		var bit = []byte{cSlf, 0x07}
+		eer := NewEncoder(bytes.NewBuffer(nil)).Encode(val)
+		der := NewDecoder(bytes.NewReader(bit)).Decode(&tmp)
+		So(eer, ShouldNotBeNil)
+		So(der, ShouldNotBeNil)
+	})
+
 	Convey("*Simple will encode and decode", t, func() {
 		var tmp Simple
 		var val = &Simple{}
@@ -953,6 +1016,66 @@ func TestGeneral(t *testing.T) {
 		So(dec, ShouldResemble, gen)
 	})
 
+	Convey("[3]float64 will encode and decode", t, func() {
+		var tmp [3]float64
+		var val = [3]float64{1, 2, 0.5}
+		var gen = []interface{}{float64(1), float64(2), float64(0.5)}
+		var bit = []byte{cFixArr + 0x03 /**/, cFloat64, 63, 240, 0, 0, 0, 0, 0, 0 /**/, cFloat64, 64, 0, 0, 0, 0, 0, 0, 0 /**/, cFloat64, 63, 224, 0, 0, 0, 0, 0, 0}
+		var enc = Encode(val)
+		var dec = Decode(bit)
+		DecodeInto(bit, &tmp)
+		So(enc, ShouldResemble, bit)
+		So(tmp, ShouldResemble, val)
+		So(dec, ShouldResemble, gen)
+	})
+
+	Convey("[4]byte will encode and decode", t, func() {
+		var tmp [4]byte
+		var val = [4]byte{1, 2, 3, 4}
+		var gen = []byte{1, 2, 3, 4}
+		var bit = []byte{cFixBin + 0x04, 1, 2, 3, 4}
+		var enc = Encode(val)
+		var dec = Decode(bit)
+		DecodeInto(bit, &tmp)
+		So(enc, ShouldResemble, bit)
+		So(tmp, ShouldResemble, val)
+		So(dec, ShouldResemble, gen)
+		DecodeInto([]byte{cFixArr + 0x04, 1, 2, 3, 4}, &tmp)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("arrays in structs will encode and decode", t, func() {
+		type Vector struct {
+			ID  [16]byte
+			Pos [3]float64
+			Tag string
+		}
+		var tmp Vector
+		var val = Vector{ID: [16]byte{15: 1}, Pos: [3]float64{1, 2, 3}, Tag: "test"}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(enc[0], ShouldEqual, cFixMap+0x03)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("arrays of the wrong length will fail to decode", t, func() {
+		var tmp [3]int
+		var bin [4]byte
+		var err *DecodeError
+		So(errors.As(NewDecoderBytes(Encode([]int{1, 2})).Decode(&tmp), &err), ShouldBeTrue)
+		So(err.Err, ShouldEqual, mismatch)
+		So(errors.As(NewDecoderBytes(Encode([]int{1, 2, 3, 4})).Decode(&tmp), &err), ShouldBeTrue)
+		So(err.Err, ShouldEqual, mismatch)
+		So(errors.As(NewDecoderBytes([]byte{cArr, cNil, 1, 2, cAlt, cAltBrk}).Decode(&tmp), &err), ShouldBeTrue)
+		So(err.Err, ShouldEqual, mismatch)
+		So(errors.As(NewDecoderBytes([]byte{cArr, cNil, 1, 2, 3, 4, cAlt, cAltBrk}).Decode(&tmp), &err), ShouldBeTrue)
+		So(err.Err, ShouldEqual, mismatch)
+		So(errors.As(NewDecoderBytes(Encode([]byte{1, 2, 3})).Decode(&bin), &err), ShouldBeTrue)
+		So(err.Err, ShouldEqual, mismatch)
+		So(NewDecoderBytes([]byte{cArr, cNil, 1, 2, 3, cAlt, cAltBrk}).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, [3]int{1, 2, 3})
+	})
+
 	Convey("[]int will encode and decode", t, func() {
 		var tmp []int
 		var val = []int{0, 1, math.MaxInt8, math.MaxInt16, math.MaxInt32, math.MaxInt64}
@@ -1378,6 +1501,10 @@ func isNotMap(v reflect.Kind) bool {
 	return v != reflect.Map && v != reflect.Struct
 }
 
+func isNotArr(v reflect.Kind) bool {
+	return v != reflect.Slice && v != reflect.Array
+}
+
 func isNotFloat(v reflect.Kind) bool {
 	return v != reflect.Float32 && v != reflect.Float64
 }
@@ -1430,6 +1557,9 @@ func TestInvariants(t *testing.T) {
 		[]complex128{1, 2, 3, math.MaxUint64},
 		[]time.Time{tme, tme, tme, tme, tme, tme},
 		[]interface{}{int8(1), "2", int16(3), int32(4), uint64(5)},
+		[2]string{"one", "two"},
+		[3]float64{1, 2, math.Pi},
+		[16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
 		Tested{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""},
 		Corked{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""},
 		Selfed{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""},
@@ -1541,96 +1671,111 @@ func TestInvariants(t *testing.T) {
 
 			// --------------------------------------------------
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []bool
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []string
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []int
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []int8
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []int16
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []int32
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []int64
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []uint
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []uint8
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []uint16
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []uint32
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []uint64
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []float32
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []float64
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []complex64
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []complex128
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []time.Time
 				tester(&out, v)
 			}
 
-			if reflect.TypeOf(v).Kind() != reflect.Slice {
+			if isNotArr(reflect.TypeOf(v).Kind()) {
 				var out []interface{}
 				tester(&out, v)
 			}
 
+			if isNotArr(reflect.TypeOf(v).Kind()) {
+				var out [2]string
+				tester(&out, v)
+			}
+
+			if isNotArr(reflect.TypeOf(v).Kind()) {
+				var out [3]float64
+				tester(&out, v)
+			}
+
+			if isNotArr(reflect.TypeOf(v).Kind()) {
+				var out [16]byte
+				tester(&out, v)
+			}
+
 			// --------------------------------------------------
 
 			if isNotMap(reflect.TypeOf(v).Kind()) {
@@ -56,6 +56,7 @@ func DecodeInto(src []byte, dst interface{}) {
 func NewDecoder(r io.Reader) *Decoder {
 	d := &Decoder{r: newReader(), h: new(Handle)}
 	d.r.r.Reset(r)
+	d.r.z = -1
 	return d
 }
 
@@ -63,6 +64,7 @@ func NewDecoder(r io.Reader) *Decoder {
 func NewDecoderBytes(b []byte) *Decoder {
 	d := &Decoder{r: newReader(), h: new(Handle)}
 	d.r.r.ResetBytes(b)
+	d.r.z = len(b)
 	return d
 }
 
@@ -72,6 +74,7 @@ func NewDecoderBytes(b []byte) *Decoder {
 func NewDecoderFromPool(r io.Reader) *Decoder {
 	d := decoders.Get().(*Decoder)
 	d.r.r.Reset(r)
+	d.r.z = -1
 	return d
 }
 
@@ -81,6 +84,7 @@ func NewDecoderFromPool(r io.Reader) *Decoder {
 func NewDecoderBytesFromPool(b []byte) *Decoder {
 	d := decoders.Get().(*Decoder)
 	d.r.r.ResetBytes(b)
+	d.r.z = len(b)
 	return d
 }
 
@@ -88,7 +92,9 @@ func NewDecoderBytesFromPool(b []byte) *Decoder {
 // Decoder was not originally from the sync pool, then the
 // Decoder is discarded.
 func (d *Decoder) Reset() {
+	d.r.reset()
 	if d.p {
+		d.h, d.r.h = new(Handle), nil
 		decoders.Put(d)
 	}
 }
@@ -110,6 +116,9 @@ When decoding into a nil interface{}, we will decode into an appropriate value b
 on the contents of the stream. When decoding into a non-nil interface{} value, the
 mode of encoding is based on the type of the value.
 
+If the Canonical option is set on the Handle, then each value is checked before it
+is decoded, and a value which is not in its canonical form will fail to decode.
+
 Example:
 
 	// Decoding into a non-nil typed value
@@ -124,13 +133,14 @@ Example:
 
 */
 func (d *Decoder) Decode(dst interface{}) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			if catch, ok := r.(error); ok {
-				err = catch
-			}
-		}
-	}()
-	d.r.DecodeAny(dst)
-	return
+	d.r.e, d.r.d = nil, len(d.r.t)
+	if d.h != nil && d.h.Canonical {
+		return d.r.decodeCanonical(dst)
+	}
+	return d.r.Decode(dst)
+}
+
+// Next reads the next token from the stream. See Reader.Next.
+func (d *Decoder) Next() (Token, error) {
+	return d.r.Next()
 }
@@ -54,10 +54,28 @@ CORK has built in support for the built-in Golang types
 	complex64
 	complex128
 	time.Time
+	time.Duration
+	big.Int
+	big.Float
+	big.Rat
 	interface{}
 	[]<T>
+	[N]<T>
 	map[<T>]<T>
 
+Fixed-size arrays are encoded as arrays, except for arrays of bytes, which are
+encoded as binary data in the same way as a []byte. When decoding into a
+fixed-size array, the number of items in the stream must match its length.
+
+Times in UTC between the years 1678 and 2262 are encoded as the nanoseconds
+since the epoch. Any other time, including the zero time, is encoded with the
+seconds and nanoseconds since the epoch, and the offset and name of its zone,
+and is decoded into a time with a fixed zone of the same offset and name.
+
+Durations and the arbitrary-precision numbers from math/big are encoded as
+types of their own, so that they are decoded back into a time.Duration, or
+into a *big.Int, *big.Float, or *big.Rat, when decoding into a nil interface.
+
 Structs
 
 When a struct is encountered whilst encoding (and that struct does not satisfy
@@ -65,6 +83,21 @@ the Corker interface) then the struct will be encoded into the stream as a map
 with the keys encoded as strings, and the values as the relevant type. Any
 struct tags describing how the struct should be encoded will be used.
 
+The fields of embedded structs are promoted into the outer struct in the same
+way as with encoding/json. Where more than one field has the same name, the
+shallowest field is used, or the tagged field if there are several at the same
+depth, and otherwise the conflicting fields are ignored. An embedded struct
+which is given a name in its tag is encoded as a single field instead.
+
+To reduce the size of encoded structs, a field can be given a small integer key
+using a tag such as `cork:"name,key=3"`, in which case the key is written in
+place of the field name. A key which is not a non-negative integer, or which is
+given to more than one field, is an error. Alternatively, the StructAsArray
+option on the Handle encodes structs as arrays of their field values, in the
+order of the fields in the struct. Structs can always be decoded from any of
+these forms, so data which was encoded with field names can still be decoded
+once keys have been added.
+
 Corkers
 
 CORK allows applications to define application-specific types to be added
@@ -75,11 +108,93 @@ decode themselves from that same binary data value.
 
 To define a custom type, an application must ensure that the type satisfies
 the Corker interface, and must then register the type using the Register
-method.
+method. Types are registered in the DefaultRegistry, unless a separate
+Registry is created and set on the Handle, which allows different parts of
+a program to use the same extension type byte for different types. Register
+panics if a different type is already registered with the same byte, whereas
+the Add method of a Registry returns an error instead.
 
 If a custom type is found in the stream when decoding, but no type with the
 specified unique byte is registered, then the binary data value will be
-decoded as a raw binary data value.
+decoded as an UnknownExt, holding the unique byte and the binary data value,
+which is encoded back into the stream exactly as it was read. Self-describing
+values (otherwise known as Selfers) carry no length, so they can not be read
+unless their type has been registered, and will fail to decode instead. When
+the FramedSelfers option is set on the Handle, Selfers are written with their
+length in the same form as a Corker, so that they can be skipped, or decoded as
+an UnknownExt, by a reader which does not know the type. A framed Selfer must
+read exactly the length of its value when decoding, otherwise decoding fails.
+
+Generated code
+
+Types which satisfy the Selfer interface encode their fields directly to the
+stream without using reflection. Rather than writing these methods by hand, the
+corkgen tool in cmd/corkgen can generate them for any struct which is marked
+with a //cork:ext directive. The generated methods write the same map of fields
+as the reflection-based encoding, but preceded by the self-describing header of
+the type. The generated methods will decode data which was written using
+reflection, but data written by the generated methods can only be decoded into
+a struct using reflection where the extension type is registered to a generated
+type with the same fields, so it can not be read by code which does not import
+the package with the generated methods.
+
+Raw values
+
+A value can be left in its encoded form by decoding it into a cork.Raw. The
+Raw will hold the exact encoded bytes of the next value in the stream, and
+will be written back into a stream verbatim when encoded, allowing values to
+be passed through without being decoded and re-encoded.
+
+Streaming
+
+Arrays and maps can be written before the number of items in them is known,
+using the BeginArray, BeginMap, EndArray and EndMap methods on the Encoder.
+These are written as indefinite-length containers, which are terminated by a
+break marker, and which are decoded into slices, maps, and structs in the same
+way as any other array or map. A stream can also be read and written one token
+at a time using the Next and EncodeToken methods.
+
+Keys
+
+The CORK format is not ordered, so encoded values can not be compared directly.
+The key package provides a separate encoding of tuples of simple values for use
+as the keys of an ordered key-value store, in which the encoded keys compare in
+the same order as the values in them.
+
+Errors
+
+When a value can not be decoded, the error returned will be a *DecodeError,
+describing the offset in the stream at which decoding failed, the path to the
+failing value (such as .Users[3].Email), and the type byte which was found in
+place of the expected value. When a value can not be encoded, the error will
+be an *EncodeError. Both can be inspected using errors.As and errors.Is. An
+error returned from the methods of a Corker or Selfer aborts the encoding or
+decoding of the value, and is returned within an *EncodeError or *DecodeError.
+
+Canonical form
+
+When the Canonical option is set on the Handle, values are encoded in canonical
+form, in which every value has exactly one encoding, so that equal values can be
+compared or hashed by their encoded bytes. Maps and structs are written with their
+entries sorted by their encoded keys, integers are always written in as few bytes
+as possible, NaN and negative zero are normalised, Raw values are rewritten into
+canonical form, and Selfers are framed, with their contents read back and rewritten
+into canonical form. A Selfer can therefore only be encoded in canonical form if
+it writes complete values which can be read back, and so can not use EncodeByte.
+The data of a Corker is written as it is returned by the Corker, which must itself
+return a single encoding for each value for it to be canonical. A Decoder with the
+same option set will refuse to decode any value which is not in canonical form,
+and IsCanonical checks whether encoded data is in canonical form without decoding
+it.
+
+Limits
+
+When decoding data from an untrusted source, the Handle can be used to limit
+the nesting depth of values, the declared lengths of arrays, maps, strings and
+binary values, and the total number of bytes read by a Decoder. A value which
+exceeds one of these limits will fail with a *LimitError. Declared lengths are
+never allocated up front beyond the size of the remaining input, so a corrupt
+length can not cause a large allocation.
 
 Types and Values
 
@@ -124,13 +239,20 @@ into the destination variable, or an integer with the necessary capacity (when
 decoding into a nil interface). When using full precision, all integers (int8,
 int16, int32, int64) and unsinged integers (uint8, uint16, uint32, uint64) are
 encoded with a fixed-length encoding format, and are able to be decoded into the
-corresponding variable type when decoding into a nil interface.
+corresponding variable type when decoding into a nil interface. Full precision is
+enabled with the FullPrecisionInts option on the Handle, in which case int and uint
+values are encoded as int64 and uint64, and the keys of struct fields are always
+encoded with as few bytes as possible.
 
 Signed integers may be received into any signed integer variable: int, int16, etc.;
 unsigned integers may be received into any unsigned integer variable; and floating
 point values may be received into any floating point variable.  However,
 the destination variable must be able to represent the value or the decode
-operation will fail.
+operation will fail. The Coercion option on the Handle allows numbers to be
+received into a variable of a different signedness, or into a float variable,
+and floats into an integer variable, when the value is converted exactly with
+CoerceLossless, or when rounded or truncated with CoerceLenient. A value which
+is out of range of the variable always fails to decode.
 
 Structs, arrays and slices are also supported. Structs encode and decode only
 exported fields. Struct tags (using the 'cork' descriptor) can specify custom key
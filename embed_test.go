@@ -0,0 +1,175 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Base struct {
+	ID   string
+	Name string
+}
+
+type Audit struct {
+	Name    string
+	Created int
+}
+
+type hidden struct {
+	Secret string
+}
+
+type User struct {
+	Base
+	Name string
+}
+
+type Owner struct {
+	*Base
+	Role string
+}
+
+type Conflict struct {
+	Base
+	Audit
+}
+
+type Tagged struct {
+	Base
+	Audit `cork:"audit"`
+}
+
+type Renamed struct {
+	Base
+	Audit
+	Title string `cork:"Name"`
+}
+
+type Nested struct {
+	User
+	Email string
+}
+
+type Hider struct {
+	hidden
+	Open string
+}
+
+type Label string
+
+type Stamp struct {
+	Label
+	Note string
+}
+
+type Node struct {
+	*Node
+	Val int
+}
+
+func TestEmbedded(t *testing.T) {
+
+	Convey("Embedded struct fields are promoted", t, func() {
+		var tmp User
+		var val = User{Base: Base{ID: "1", Name: "base"}, Name: "user"}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(Decode(enc), ShouldResemble, Decode(Encode(map[string]string{"ID": "1", "Name": "user"})))
+		So(tmp, ShouldResemble, User{Base: Base{ID: "1"}, Name: "user"})
+	})
+
+	Convey("Embedded struct pointers are promoted and allocated", t, func() {
+		var tmp Owner
+		var val = Owner{Base: &Base{ID: "1", Name: "base"}, Role: "admin"}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(Decode(enc), ShouldResemble, Decode(Encode(map[string]string{"ID": "1", "Name": "base", "Role": "admin"})))
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Nil embedded struct pointers are skipped", t, func() {
+		var tmp Owner
+		var val = Owner{Role: "admin"}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(Decode(enc), ShouldResemble, Decode(Encode(map[string]string{"Role": "admin"})))
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Conflicting fields at the same depth are ignored", t, func() {
+		var tmp map[string]interface{}
+		var val = Conflict{Base: Base{ID: "1", Name: "base"}, Audit: Audit{Name: "audit", Created: 5}}
+		DecodeInto(Encode(val), &tmp)
+		So(tmp, ShouldResemble, map[string]interface{}{"ID": "1", "Created": 5})
+	})
+
+	Convey("Tagged fields take precedence at the same depth", t, func() {
+		var tmp Renamed
+		var val = Renamed{Base: Base{ID: "1", Name: "base"}, Audit: Audit{Name: "audit", Created: 5}, Title: "title"}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(Decode(enc), ShouldResemble, Decode(Encode(map[string]interface{}{"ID": "1", "Created": 5, "Name": "title"})))
+		So(tmp, ShouldResemble, Renamed{Base: Base{ID: "1"}, Audit: Audit{Created: 5}, Title: "title"})
+	})
+
+	Convey("Tagged embedded structs are not promoted", t, func() {
+		var tmp Tagged
+		var val = Tagged{Base: Base{ID: "1", Name: "base"}, Audit: Audit{Name: "audit", Created: 5}}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(Decode(enc), ShouldResemble, Decode(Encode(map[string]interface{}{"ID": "1", "Name": "base", "audit": map[string]interface{}{"Name": "audit", "Created": 5}})))
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Deeply embedded struct fields are promoted", t, func() {
+		var tmp Nested
+		var val = Nested{User: User{Base: Base{ID: "1"}, Name: "user"}, Email: "a@b.c"}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(Decode(enc), ShouldResemble, Decode(Encode(map[string]string{"ID": "1", "Name": "user", "Email": "a@b.c"})))
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Unexported embedded struct fields are promoted", t, func() {
+		var tmp Hider
+		var val = Hider{hidden: hidden{Secret: "shh"}, Open: "open"}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(Decode(enc), ShouldResemble, Decode(Encode(map[string]string{"Secret": "shh", "Open": "open"})))
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Embedded types which are not structs are not promoted", t, func() {
+		var tmp Stamp
+		var val = Stamp{Label: "label", Note: "note"}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(Decode(enc), ShouldResemble, Decode(Encode(map[string]string{"Label": "label", "Note": "note"})))
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Recursively embedded structs are promoted once", t, func() {
+		var tmp Node
+		var val = Node{Node: &Node{Val: 1}, Val: 2}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(Decode(enc), ShouldResemble, Decode(Encode(map[string]int{"Val": 2})))
+		So(tmp, ShouldResemble, Node{Val: 2})
+	})
+
+}
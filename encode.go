@@ -88,7 +88,10 @@ func NewEncoderBytesFromPool(b *[]byte) *Encoder {
 // the sync pool. If the Encoder was not originally from the
 // sync pool, then the Encoder is discarded.
 func (e *Encoder) Reset() {
+	e.w.t = e.w.t[:0]
+	e.w.e = nil
 	if e.p {
+		e.h, e.w.h = new(Handle), nil
 		encoders.Put(e)
 	}
 }
@@ -137,14 +140,68 @@ Example:
 
 */
 func (e *Encoder) Encode(src interface{}) (err error) {
+	e.w.e = nil
+	if err = e.w.Encode(src); err != nil {
+		return
+	}
+	return e.w.w.Flush()
+}
+
+/*
+BeginArray starts an indefinite-length array in the stream. Values
+can then be written using Encode, before the array is closed using
+EndArray. This allows values to be streamed as they are produced,
+without needing to know up front how many values there will be.
+
+Example:
+
+	enc := cork.NewEncoder(w)
+	enc.BeginArray()
+	for rows.Next() {
+		enc.Encode(rows.Value())
+	}
+	enc.EndArray()
+
+*/
+func (e *Encoder) BeginArray() error {
+	return e.token(e.w.BeginArray)
+}
+
+// EndArray closes the array which was most recently started.
+func (e *Encoder) EndArray() error {
+	return e.token(e.w.EndArray)
+}
+
+// BeginMap starts an indefinite-length map in the stream. Keys and
+// values can then be written in turn using Encode, before the map
+// is closed using EndMap.
+func (e *Encoder) BeginMap() error {
+	return e.token(e.w.BeginMap)
+}
+
+// EndMap closes the map which was most recently started.
+func (e *Encoder) EndMap() error {
+	return e.token(e.w.EndMap)
+}
+
+// EncodeToken writes a single token to the stream. See Writer.EncodeToken.
+func (e *Encoder) EncodeToken(t Token) error {
+	return e.token(func() {
+		e.w.EncodeToken(t)
+	})
+}
+
+// token runs fn, and flushes the stream once the
+// outermost array or map has been closed.
+func (e *Encoder) token(fn func()) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			if catch, ok := r.(error); ok {
-				err = catch
-			}
+			err = e.w.wrap(r)
 		}
 	}()
-	e.w.EncodeAny(src)
-	e.w.w.Flush()
+	fn()
+	if len(e.w.t) == 0 {
+		return e.w.w.Flush()
+	}
 	return
 }
@@ -14,6 +14,273 @@
 
 package cork
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
 
 var fail = errors.New("Can't decode into type")
+
+var invalid = errors.New("Can't encode invalid token")
+
+var unbegun = errors.New("Can't end an array or map which has not begun")
+
+var overflow = errors.New("Length is too large")
+
+var mismatch = errors.New("Length does not match array")
+
+var unregistered = errors.New("No self-describing type is registered with extension")
+
+var unframed = errors.New("Self-describing value did not read all of its length")
+
+var badkey = errors.New("Field has an invalid integer key")
+
+var dupkey = errors.New("Field has the same integer key as another field")
+
+var malformed = errors.New("Arbitrary-precision number is not valid")
+
+var outranged = errors.New("Number is out of range")
+
+var inexact = errors.New("Number can not be represented exactly")
+
+var noncanonical = errors.New("Value is not in canonical form")
+
+var duplicate = errors.New("Map has more than one key with the same encoding")
+
+var unbounded = errors.New("Can't write an indefinite-length value in canonical form")
+
+// recovered converts a value recovered from a panic into
+// an error, so that panics which were not raised with an
+// error value are not lost when they are recovered.
+func recovered(v interface{}) error {
+	switch e := v.(type) {
+	case nil:
+		return nil
+	case error:
+		return e
+	default:
+		return fmt.Errorf("%v", e)
+	}
+}
+
+// DecodeError describes a value which could not be decoded,
+// along with where in the stream, and where in the value being
+// decoded into, the failure occurred.
+type DecodeError struct {
+	// Offset is the position in the stream of the byte
+	// at which decoding failed.
+	Offset int
+	// Path is the location of the failing value within
+	// the value being decoded into, such as .Users[3].Email.
+	Path string
+	// Want is the kind of value which was expected, such as
+	// str or int, if the wrong type of value was found.
+	Want string
+	// Got is the type byte which was found in the stream,
+	// if the wrong type of value was found.
+	Got byte
+	// Type is the Go type which was being decoded into,
+	// if it is known.
+	Type reflect.Type
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	var s string
+	switch {
+	case e.Want != "":
+		s = fmt.Sprintf("cork: can't decode 0x%02X as %s", e.Got, e.Want)
+		if e.Type != nil {
+			s += " into " + e.Type.String()
+		}
+	default:
+		s = "cork: " + e.Err.Error()
+		if e.Type != nil {
+			s += " whilst decoding " + e.Type.String()
+		}
+	}
+	if e.Path != "" {
+		s += " at " + e.Path
+	}
+	return s + " (offset " + strconv.Itoa(e.Offset) + ")"
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// LimitError is the underlying error of a DecodeError
+// when decoding a value would exceed one of the limits
+// set on the Handle.
+type LimitError struct {
+	// Limit is the name of the Handle field
+	// which was exceeded, such as MaxDepth.
+	Limit string
+	// Max is the value of the limit.
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("exceeded %s limit of %d", e.Limit, e.Max)
+}
+
+// EncodeError describes a value which could not be encoded,
+// along with where in the value being encoded the failure
+// occurred.
+type EncodeError struct {
+	// Path is the location of the failing value within
+	// the value being encoded, such as .Users[3].Email.
+	Path string
+	// Type is the Go type which was being encoded,
+	// if it is known.
+	Type reflect.Type
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *EncodeError) Error() string {
+	s := "cork: " + e.Err.Error()
+	if e.Type != nil {
+		s += " whilst encoding " + e.Type.String()
+	}
+	if e.Path != "" {
+		s += " at " + e.Path
+	}
+	return s
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// step describes one level of the path to a value, which
+// is used for reporting where an error occurred. Struct
+// fields and string map keys are described by their name,
+// and array elements and other map keys by their index or
+// their reflected value.
+type step struct {
+	kind byte
+	name string
+	indx int
+	vkey reflect.Value
+	elem reflect.Type
+}
+
+const (
+	stepIndex byte = iota
+	stepField
+	stepKey
+)
+
+func (p *step) String() string {
+	switch {
+	case p.kind == stepField:
+		return "." + p.name
+	case p.kind == stepKey && p.vkey.IsValid():
+		if p.vkey.Kind() == reflect.String {
+			return "[" + strconv.Quote(p.vkey.String()) + "]"
+		}
+		return fmt.Sprintf("[%v]", p.vkey.Interface())
+	case p.kind == stepKey && p.name != "":
+		return "[" + strconv.Quote(p.name) + "]"
+	default:
+		return "[" + strconv.Itoa(p.indx) + "]"
+	}
+}
+
+// trace annotates any error which occurs whilst decoding
+// a value inside an array, map, or struct, with the step
+// which leads to that value. It must be deferred.
+func (r *Reader) trace(p *step) {
+	if v := recover(); v != nil {
+		e := r.wrap(v)
+		if e.Type == nil {
+			e.Type = p.elem
+		}
+		e.Path = p.String() + e.Path
+		panic(e)
+	}
+}
+
+// wrap converts a value recovered from a panic into a
+// DecodeError. Reaching the end of the stream part way
+// through a value is reported as io.ErrUnexpectedEOF.
+func (r *Reader) wrap(v interface{}) *DecodeError {
+	if e, ok := v.(*DecodeError); ok {
+		return e
+	}
+	err := recovered(v)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return &DecodeError{Offset: r.n, Err: err}
+}
+
+// error converts a value recovered from a panic into a
+// DecodeError, setting the type being decoded into if
+// the error occurred at the top level of the value.
+func (r *Reader) error(v interface{}, dst interface{}) *DecodeError {
+	e := r.wrap(v)
+	if e.Type == nil && e.Path == "" {
+		e.Type = typeOf(dst)
+	}
+	return e
+}
+
+// unexpected returns a DecodeError for a type byte which
+// was not of the expected kind, where the type byte was the
+// last byte which was read from the stream, and v is the
+// value, or the type of the value, being decoded into.
+func (r *Reader) unexpected(want string, v interface{}) *DecodeError {
+	return &DecodeError{Offset: r.n - 1, Want: want, Got: r.l, Type: typeOf(v), Err: fail}
+}
+
+// typeOf returns the type of the value being decoded into
+// or encoded, where v is a value, a pointer to a value, or
+// a reflect.Type or reflect.Value describing the value.
+func typeOf(v interface{}) reflect.Type {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case reflect.Type:
+		return t
+	case reflect.Value:
+		if t.IsValid() {
+			return t.Type()
+		}
+		return nil
+	default:
+		r := reflect.TypeOf(v)
+		if r.Kind() == reflect.Ptr {
+			return r.Elem()
+		}
+		return r
+	}
+}
+
+// trace annotates any error which occurs whilst encoding
+// a value inside an array, map, or struct, with the step
+// which leads to that value. It must be deferred.
+func (w *Writer) trace(p *step) {
+	if v := recover(); v != nil {
+		e := w.wrap(v)
+		if e.Type == nil {
+			e.Type = p.elem
+		}
+		e.Path = p.String() + e.Path
+		panic(e)
+	}
+}
+
+// wrap converts a value recovered from a panic into
+// an EncodeError.
+func (w *Writer) wrap(v interface{}) *EncodeError {
+	if e, ok := v.(*EncodeError); ok {
+		return e
+	}
+	return &EncodeError{Err: recovered(v)}
+}
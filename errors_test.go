@@ -0,0 +1,234 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Panicked struct{}
+
+func (this *Panicked) ExtendCORK() byte {
+	return 0x05
+}
+
+func (this *Panicked) MarshalCORK(w *Writer) (err error) {
+	panic("Marshal panic")
+}
+
+func (this *Panicked) UnmarshalCORK(r *Reader) (err error) {
+	panic("Unmarshal panic")
+}
+
+type Guarded struct {
+	Name  string
+	Count int
+}
+
+func (this *Guarded) ExtendCORK() byte {
+	return 0x06
+}
+
+func (this *Guarded) MarshalCORK(w *Writer) (err error) {
+	w.Encode(this.Name)
+	w.Encode(this.Count)
+	return w.Err()
+}
+
+func (this *Guarded) UnmarshalCORK(r *Reader) (err error) {
+	r.Decode(&this.Name)
+	r.Decode(&this.Count)
+	return r.Err()
+}
+
+func init() {
+	Register(&Panicked{})
+	Register(&Guarded{})
+}
+
+func TestErrors(t *testing.T) {
+
+	Convey("Non-error panics will be returned as errors", t, func() {
+		var tmp Panicked
+		eer := NewEncoder(bytes.NewBuffer(nil)).Encode(&Panicked{})
+		der := NewDecoderBytes([]byte{cSlf, 0x05}).Decode(&tmp)
+		So(eer, ShouldNotBeNil)
+		So(eer.Error(), ShouldEqual, "cork: Marshal panic whilst encoding cork.Panicked")
+		So(der, ShouldNotBeNil)
+		So(der.Error(), ShouldEqual, "cork: Unmarshal panic whilst decoding cork.Panicked (offset 2)")
+	})
+
+	Convey("Selfers can use the error-returning methods", t, func() {
+		var tmp Guarded
+		var val = &Guarded{Name: "test", Count: 25}
+		So(NewDecoderBytes(Encode(val)).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, *val)
+	})
+
+	Convey("Errors within a Selfer will be returned from Decode", t, func() {
+		var tmp Guarded
+		var bit = []byte{cSlf, 0x06, cFixStr + 4, 't', 'e', 's', 't', cTrue}
+		So(errors.Is(NewDecoderBytes(bit).Decode(&tmp), fail), ShouldBeTrue)
+	})
+
+	Convey("Errors returned by a Selfer will be returned from Encode", t, func() {
+		var e *EncodeError
+		err := NewEncoder(bytes.NewBuffer(nil)).Encode([]interface{}{&Failed{}})
+		So(errors.As(err, &e), ShouldBeTrue)
+		So(e.Type, ShouldEqual, reflect.TypeOf(Failed{}))
+		So(e.Path, ShouldEqual, "[0]")
+		So(err.Error(), ShouldEqual, "cork: slf 0x07: Marshal error whilst encoding cork.Failed at [0]")
+	})
+
+	Convey("Errors returned by a Selfer will be returned from Decode", t, func() {
+		var e *DecodeError
+		var tmp []*Failed
+		err := NewDecoderBytes([]byte{cFixArr + 1, cSlf, 0x07}).Decode(&tmp)
+		So(errors.As(err, &e), ShouldBeTrue)
+		So(e.Type, ShouldEqual, reflect.TypeOf(Failed{}))
+		So(e.Offset, ShouldEqual, 3)
+		So(err.Error(), ShouldEqual, "cork: slf 0x07: Unmarshal error whilst decoding cork.Failed at [0] (offset 3)")
+	})
+
+	Convey("Errors returned by a framed Selfer abort the value", t, func() {
+		var buf []byte
+		var tmp Failed
+		err := NewEncoderBytes(&buf).Options(&Handle{FramedSelfers: true}).Encode(&Failed{})
+		So(err, ShouldNotBeNil)
+		So(buf, ShouldBeEmpty)
+		err = NewDecoderBytes([]byte{cFixExt, 0x07}).Decode(&tmp)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "slf 0x07")
+	})
+
+	Convey("Errors from Decode within a Selfer are not wrapped again", t, func() {
+		var tmp Guarded
+		var bit = []byte{cFixExt + 6, 0x06, cFixStr + 4, 't', 'e', 's', 't', cTrue}
+		err := NewDecoderBytes(bit).Decode(&tmp)
+		So(errors.Is(err, fail), ShouldBeTrue)
+		So(err.Error(), ShouldNotContainSubstring, "slf")
+	})
+
+	Convey("Reader errors will be retained", t, func() {
+		var one string
+		var two int
+		r := newReader()
+		r.r.ResetBytes([]byte{cTrue, 1})
+		err := r.Decode(&one)
+		So(errors.Is(err, fail), ShouldBeTrue)
+		So(r.Decode(&two), ShouldEqual, err)
+		So(r.Err(), ShouldEqual, err)
+	})
+
+	Convey("Reader errors will not panic outside of a Decoder", t, func() {
+		var tmp Guarded
+		r := newReader()
+		r.r.ResetBytes([]byte{cFixStr + 4, 't', 'e', 's'})
+		So(func() { tmp.UnmarshalCORK(r) }, ShouldNotPanic)
+		So(errors.Is(r.Err(), io.ErrUnexpectedEOF), ShouldBeTrue)
+	})
+
+	Convey("Writer errors will be retained", t, func() {
+		w := newWriter()
+		w.w.ResetBytes(new([]byte))
+		So(w.Encode(&Errord{}), ShouldNotBeNil)
+		So(w.Encode(1), ShouldNotBeNil)
+		So(w.Err(), ShouldNotBeNil)
+	})
+
+	Convey("Decoders will decode again after an error", t, func() {
+		var one string
+		var two int
+		dec := NewDecoderBytes([]byte{cTrue, 1})
+		So(errors.Is(dec.Decode(&one), fail), ShouldBeTrue)
+		So(dec.Decode(&two), ShouldBeNil)
+		So(two, ShouldEqual, 1)
+	})
+
+}
+
+type Account struct {
+	Name  string
+	Users []*Person
+	Roles map[string]interface{}
+}
+
+type Person struct {
+	Email string
+	Age   int
+}
+
+func TestErrorPaths(t *testing.T) {
+
+	Convey("Decode errors will describe the failing value", t, func() {
+		var tmp Account
+		var err *DecodeError
+		var bit = Encode(map[string]interface{}{
+			"Name":  "test",
+			"Users": []interface{}{map[string]interface{}{"Email": "one"}, map[string]interface{}{"Email": true}},
+		})
+		So(errors.As(NewDecoderBytes(bit).Decode(&tmp), &err), ShouldBeTrue)
+		So(err.Path, ShouldEqual, ".Users[1].Email")
+		So(err.Want, ShouldEqual, "str")
+		So(err.Got, ShouldEqual, cTrue)
+		So(err.Type, ShouldEqual, reflect.TypeOf(""))
+		So(bit[err.Offset], ShouldEqual, cTrue)
+		So(errors.Is(err, fail), ShouldBeTrue)
+		So(err.Error(), ShouldEqual, fmt.Sprintf("cork: can't decode 0xE1 as str into string at .Users[1].Email (offset %d)", err.Offset))
+	})
+
+	Convey("Decode errors will describe map keys", t, func() {
+		var tmp Account
+		var err *DecodeError
+		var bit = Encode(map[string]interface{}{
+			"Roles": map[string]interface{}{"admin": &Errord{}},
+		})
+		bit = bytes.Replace(bit, []byte{cFixExt, 0x00}, []byte{cFixExt, 0x06}, 1)
+		So(errors.As(NewDecoderBytes(bit).Decode(&tmp), &err), ShouldBeTrue)
+		So(err.Path, ShouldEqual, `.Roles["admin"]`)
+	})
+
+	Convey("Decode errors will report truncated input", t, func() {
+		var tmp Account
+		var err *DecodeError
+		var bit = Encode(map[string]interface{}{"Users": []interface{}{&Person{Email: "one", Age: 300}}})
+		So(errors.As(NewDecoderBytes(bit[:len(bit)-1]).Decode(&tmp), &err), ShouldBeTrue)
+		So(err.Path, ShouldEqual, ".Users[0].Age")
+		So(err.Offset, ShouldEqual, len(bit)-2)
+		So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+	})
+
+	Convey("Decoding from an empty stream will return io.EOF", t, func() {
+		var tmp Account
+		So(NewDecoderBytes([]byte{}).Decode(&tmp), ShouldEqual, io.EOF)
+	})
+
+	Convey("Encode errors will describe the failing value", t, func() {
+		var err *EncodeError
+		var val = map[string]interface{}{"Items": []interface{}{1, &Errord{}}}
+		So(errors.As(NewEncoder(bytes.NewBuffer(nil)).Encode(val), &err), ShouldBeTrue)
+		So(err.Path, ShouldEqual, `["Items"][1]`)
+		So(err.Type, ShouldEqual, reflect.TypeOf(Errord{}))
+		So(err.Err.Error(), ShouldEqual, "Marshal error")
+	})
+
+}
@@ -15,15 +15,203 @@
 package cork
 
 import (
+	"bytes"
+	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 type field struct {
 	omit bool
+	keyd bool
+	key  int
 	indx []int
 	name string
 	show string
+	val  *plan // plan for the type of the field
+	ptr  *plan // plan for a pointer to the field
+}
+
+// newFields returns the fields of a struct type which can
+// be encoded and decoded, including the fields of embedded
+// structs, which are promoted in the same way as they are
+// in Go and in encoding/json. Where more than one field
+// has the same name, the shallowest field is used, and if
+// there is more than one at the same depth, then the one
+// with a tag is used, otherwise all of them are ignored.
+// An error is returned if a field has an invalid integer
+// key, or if more than one field has the same key.
+func newFields(t reflect.Type) ([]*field, error) {
+
+	type embed struct {
+		kind reflect.Type
+		indx []int
+	}
+
+	var all []*field
+
+	seen := map[reflect.Type]bool{}
+
+	for next := []embed{{kind: t}}; len(next) > 0; {
+
+		curr := next
+		next = nil
+
+		// A type which was embedded at a shallower
+		// depth is not expanded again, which stops
+		// recursive types from repeating forever.
+
+		for _, e := range curr {
+			if seen[e.kind] {
+				continue
+			}
+			for i := 0; i < e.kind.NumField(); i++ {
+				s := e.kind.Field(i)
+				x := append(append([]int{}, e.indx...), i)
+				if k := embedded(s); k != nil {
+					next = append(next, embed{kind: k, indx: x})
+					continue
+				}
+				f, err := newField(s)
+				if err != nil {
+					return nil, err
+				}
+				if f != nil {
+					f.indx = x
+					all = append(all, f)
+				}
+			}
+		}
+
+		for _, e := range curr {
+			seen[e.kind] = true
+		}
+
+	}
+
+	fls := make([]*field, 0, len(all))
+
+	for _, f := range all {
+		if dominant(f, all) {
+			fls = append(fls, f)
+		}
+	}
+
+	sort.SliceStable(fls, func(i, j int) bool {
+		x, y := fls[i].indx, fls[j].indx
+		for k := 0; k < len(x) && k < len(y); k++ {
+			if x[k] != y[k] {
+				return x[k] < y[k]
+			}
+		}
+		return len(x) < len(y)
+	})
+
+	keys := make(map[int]*field)
+
+	for _, f := range fls {
+		if !f.keyd {
+			continue
+		}
+		if o, ok := keys[f.key]; ok {
+			return nil, fmt.Errorf("fields %s and %s: %w", o.name, f.name, dupkey)
+		}
+		keys[f.key] = f
+	}
+
+	return fls, nil
+
+}
+
+// dominant checks whether a field is the one which should
+// be used out of all of the fields with the same name.
+func dominant(f *field, all []*field) bool {
+	for _, o := range all {
+		if o == f || o.Name() != f.Name() {
+			continue
+		}
+		switch {
+		case len(o.indx) < len(f.indx):
+			return false
+		case len(o.indx) > len(f.indx):
+			continue
+		case len(f.show) == 0 || len(o.show) > 0:
+			return false
+		}
+	}
+	return true
+}
+
+// embedded returns the struct type of an embedded field
+// whose fields should be promoted, or nil if the field is
+// not embedded, is named using a tag, or is a type which
+// encodes itself, such as a Corker, Selfer or time.Time.
+func embedded(kind reflect.StructField) reflect.Type {
+
+	if !kind.Anonymous {
+		return nil
+	}
+
+	if tag := kind.Tag.Get(tag); tag == "-" || strings.Split(tag, ",")[0] != "" {
+		return nil
+	}
+
+	t := kind.Type
+
+	if t.Kind() == reflect.Ptr {
+		// Unexported pointers can't be allocated
+		if len(kind.PkgPath) > 0 {
+			return nil
+		}
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || isNative(t) {
+		return nil
+	}
+
+	if c.Selfable(reflect.PtrTo(t)) || c.Corkable(reflect.PtrTo(t)) {
+		return nil
+	}
+
+	return t
+
+}
+
+// fieldOf returns the field of the struct at the index,
+// or an invalid value if an embedded pointer is nil.
+func fieldOf(v reflect.Value, indx []int) reflect.Value {
+	for i, x := range indx {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// fieldFor returns the field of the struct at the index,
+// allocating any nil embedded pointers on the way to it,
+// or an invalid value if a pointer can not be allocated.
+func fieldFor(v reflect.Value, indx []int) reflect.Value {
+	for i, x := range indx {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
 }
 
 func (f *field) Name() string {
@@ -33,70 +221,72 @@ func (f *field) Name() string {
 	return f.name
 }
 
-func newField(kind reflect.StructField) *field {
+// encodedKey returns the key of the field as it is encoded.
+func (f *field) encodedKey() (b []byte) {
+	w := NewEncoderBytes(&b).w
+	if f.keyd {
+		w.encodeInt(f.key)
+	} else {
+		w.EncodeString(f.Name())
+	}
+	return
+}
+
+// sortFields returns the fields sorted by their encoded keys,
+// which is the order in which they are written in canonical form.
+func sortFields(fls []*field) []*field {
+	srt := append([]*field(nil), fls...)
+	sort.SliceStable(srt, func(i, j int) bool {
+		return bytes.Compare(srt[i].encodedKey(), srt[j].encodedKey()) < 0
+	})
+	return srt
+}
+
+func newField(kind reflect.StructField) (*field, error) {
 
 	// Field is private
 	if len(kind.PkgPath) > 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Field not supported
 	switch kind.Type.Kind() {
 	case reflect.Chan:
-		return nil
+		return nil, nil
 	case reflect.Func:
-		return nil
+		return nil, nil
 	}
 
 	// Retrieve the tag
 	tag := kind.Tag.Get(tag)
 
-	// No tag specified
-	if len(tag) == 0 {
-		return &field{
-			omit: false,
-			name: kind.Name,
-			indx: kind.Index,
-		}
-	}
-
 	// Field is ignored
 	if tag == "-" {
-		return nil
+		return nil, nil
 	}
 
-	idx := strings.Index(tag, ",")
+	opts := strings.Split(tag, ",")
 
-	// Field is renamed
-	if idx < 0 {
-		return &field{
-			omit: false,
-			name: kind.Name,
-			show: tag,
-			indx: kind.Index,
-		}
-	}
-
-	// Field is renamed
-	if idx > 0 {
-		return &field{
-			omit: tag[idx+1:] == "omitempty",
-			name: kind.Name,
-			show: tag[:idx],
-			indx: kind.Index,
-		}
+	f := &field{
+		name: kind.Name,
+		show: opts[0],
+		indx: kind.Index,
 	}
 
-	// Immediate comma
-	if idx == 0 {
-		return &field{
-			omit: tag[idx+1:] == "omitempty",
-			name: kind.Name,
-			indx: kind.Index,
+	for _, o := range opts[1:] {
+		switch {
+		case o == "omitempty":
+			f.omit = true
+		case strings.HasPrefix(o, "key="):
+			k, err := strconv.Atoi(o[4:])
+			if err != nil || k < 0 {
+				return nil, fmt.Errorf("field %s: %w", kind.Name, badkey)
+			}
+			f.keyd, f.key = true, k
 		}
 	}
 
-	return nil
+	return f, nil
 
 }
 
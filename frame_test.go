@@ -0,0 +1,146 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Counted struct {
+	N, M int
+}
+
+func (this *Counted) ExtendCORK() byte {
+	return 0x40
+}
+
+func (this *Counted) MarshalCORK(w *Writer) (err error) {
+	for i := 0; i < this.N; i++ {
+		w.EncodeInt(i)
+	}
+	return
+}
+
+func (this *Counted) UnmarshalCORK(r *Reader) (err error) {
+	for i := 0; i < this.M; i++ {
+		var x int
+		r.DecodeInt(&x)
+	}
+	return
+}
+
+type Labelled struct {
+	Name string
+}
+
+func (this *Labelled) ExtendCORK() byte {
+	return 0x41
+}
+
+func (this *Labelled) MarshalCORK(w *Writer) (err error) {
+	w.EncodeMapLen(1)
+	w.EncodeString("Name")
+	w.EncodeString(this.Name)
+	return
+}
+
+func (this *Labelled) UnmarshalCORK(r *Reader) (err error) {
+	for i, s := 0, r.DecodeMapLen(); i < s; i++ {
+		r.DecodeKey()
+		r.DecodeString(&this.Name)
+	}
+	return
+}
+
+func framed(src interface{}) (dst []byte) {
+	NewEncoderBytes(&dst).Options(&Handle{FramedSelfers: true}).Encode(src)
+	return
+}
+
+func TestFramed(t *testing.T) {
+
+	val := &Selfed{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""}
+
+	Convey("Framed Selfers are written with their length", t, func() {
+		bit := Encode(val)
+		enc := framed(val)
+		So(enc[:2], ShouldResemble, []byte{cExt8, byte(len(bit) - 2)})
+		So(enc[2:], ShouldResemble, bit[1:])
+	})
+
+	Convey("Framed Selfers will encode and decode", t, func() {
+		var tmp Selfed
+		DecodeInto(framed(val), &tmp)
+		So(tmp, ShouldResemble, *val)
+		So(Decode(framed(val)), ShouldResemble, val)
+		So(Decode(framed([]interface{}{val, 1})), ShouldResemble, []interface{}{val, 1})
+	})
+
+	Convey("Unframed Selfers still decode", t, func() {
+		var tmp Selfed
+		NewDecoderBytes(Encode(val)).Options(&Handle{FramedSelfers: true}).Decode(&tmp)
+		So(tmp, ShouldResemble, *val)
+	})
+
+	Convey("Unregistered framed Selfers decode as UnknownExt", t, func() {
+		enc := framed(&Counted{N: 2})
+		So(Decode(enc), ShouldResemble, &UnknownExt{Type: 0x40, Data: []byte{0, 1}})
+		So(Encode(Decode(enc)), ShouldResemble, enc)
+	})
+
+	Convey("Unregistered framed Selfers can be skipped", t, func() {
+		var tmp Wrapper
+		err := NewDecoderBytes(framed(map[string]interface{}{"X": &Counted{N: 2}})).Decode(&tmp)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Registered framed Selfers decode into a nil interface", t, func() {
+		var tmp interface{}
+		reg := NewRegistry()
+		So(reg.Add(&Labelled{}), ShouldBeNil)
+		So(NewDecoderBytes(framed(&Labelled{Name: "test"})).Options(&Handle{Registry: reg}).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, &Labelled{Name: "test"})
+	})
+
+	Convey("Framed Selfers which read too little fail", t, func() {
+		err := NewDecoderBytes(framed(&Counted{N: 2})).Decode(&Counted{M: 1})
+		So(errors.Is(err, unframed), ShouldBeTrue)
+		So(err.(*DecodeError).Offset, ShouldEqual, 3)
+	})
+
+	Convey("Framed Selfers which read too much fail", t, func() {
+		enc := append(framed(&Counted{N: 1}), Encode(1)...)
+		So(NewDecoderBytes(append(Encode(&Counted{N: 1}), Encode(1)...)).Decode(&Counted{M: 2}), ShouldBeNil)
+		So(errors.Is(NewDecoderBytes(enc).Decode(&Counted{M: 2}), io.ErrUnexpectedEOF), ShouldBeTrue)
+	})
+
+	Convey("Framed Selfers do not decode into unrelated structs", t, func() {
+		var tmp struct{ Name string }
+		var err *DecodeError
+		So(errors.As(NewDecoderBytes(framed(&Labelled{Name: "test"})).Decode(&tmp), &err), ShouldBeTrue)
+		So(err.Got, ShouldEqual, 0x41)
+		So(tmp.Name, ShouldBeEmpty)
+	})
+
+	Convey("Selfers which are not generated do not decode from maps", t, func() {
+		var tmp Labelled
+		So(NewDecoderBytes(Encode(map[string]string{"Name": "test"})).Decode(&tmp), ShouldNotBeNil)
+	})
+
+}
@@ -0,0 +1,242 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package cork
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// fuzzed is the Handle used when fuzzing, so that
+// hostile input can not exhaust the stack or memory.
+var fuzzed = &Handle{
+	SortMaps:      true,
+	MaxDepth:      100,
+	MaxTotalBytes: 1 << 20,
+}
+
+func fuzzSeeds(f *testing.F) {
+
+	tme, _ := time.Parse(time.RFC3339, "1987-06-22T08:00:00.123456789Z")
+
+	for _, v := range []interface{}{
+		nil,
+		true,
+		false,
+		str,
+		bin,
+		lng,
+		tme,
+		int8(math.MinInt8),
+		int64(math.MaxInt64),
+		uint64(math.MaxUint64),
+		float32(math.Pi),
+		float64(math.Pi),
+		complex64(math.Pi),
+		complex128(math.Pi),
+		[]bool{true, false},
+		[]string{"one", "two"},
+		[]int{1, 2, 3, math.MaxInt8},
+		[]uint64{1, 2, 3, math.MaxUint64},
+		[]float64{1, 2, 3, math.Pi},
+		[]time.Time{tme, tme},
+		[]interface{}{int8(1), "2", int16(3), int32(4), uint64(5)},
+		Tested{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""},
+		&Corked{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""},
+		&Selfed{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""},
+		&Complex{String: "test", Int: 1, Time: tme, Bytes: bin},
+		map[string]interface{}{
+			"1": "test",
+			"2": map[interface{}]interface{}{
+				true: []byte("Check"),
+			},
+			"3": map[interface{}]interface{}{
+				"str": str,
+				"bin": bin,
+			},
+		},
+	} {
+		f.Add(Encode(v))
+	}
+
+	// Indefinite-length containers.
+
+	f.Add([]byte{cArr, cNil, cFixInt + 1, cFixStr + 1, 'a', cAlt, cAltBrk})
+	f.Add([]byte{cMap, cNil, cFixStr + 1, 'a', cTrue, cAlt, cAltBrk})
+
+	// Truncated and malformed values.
+
+	f.Add([]byte{cArr, cUint32, 0x7F, 0xFF, 0xFF, 0xFF, cNil})
+	f.Add([]byte{cStr64, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+	f.Add([]byte{cSlf, 0xFF})
+	f.Add([]byte{cAlt})
+
+}
+
+// FuzzDecode checks that any input can be decoded into
+// an interface, skipped, and read as tokens, returning an
+// error rather than panicking when the input is invalid.
+func FuzzDecode(f *testing.F) {
+
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+
+		var v interface{}
+		NewDecoderBytes(b).Options(fuzzed).Decode(&v)
+
+		var r Raw
+		NewDecoderBytes(b).Options(fuzzed).Decode(&r)
+
+		var m map[string]interface{}
+		NewDecoderBytes(b).Options(&Handle{MaxDepth: 100, MapType: m}).Decode(&m)
+
+		// Each byte can begin at most one token, and
+		// each container can end at most once, so the
+		// stream must end within twice as many tokens.
+
+		dec := NewDecoder(bytes.NewReader(b)).Options(fuzzed)
+		for i := 0; i <= 2*len(b); i++ {
+			if _, err := dec.Next(); err != nil {
+				return
+			}
+		}
+
+		t.Fatalf("read too many tokens from %x", b)
+
+	})
+
+}
+
+// FuzzDecodeInto checks that any input can be decoded
+// into structs of several different shapes, returning an
+// error rather than panicking when the input is invalid.
+func FuzzDecodeInto(f *testing.F) {
+
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+
+		for _, dst := range []interface{}{
+			new(Tested),
+			new(Corked),
+			new(Selfed),
+			new(Complex),
+			new(Custom),
+			new([]Tested),
+			new(map[string]*Tested),
+			new(struct {
+				Arr [4]int
+				Ptr **string
+				Any interface{}
+			}),
+		} {
+			NewDecoderBytes(b).Options(fuzzed).Decode(dst)
+			NewDecoder(bytes.NewReader(b)).Options(fuzzed).Decode(dst)
+		}
+
+	})
+
+}
+
+// FuzzRoundTrip checks that any input which can be decoded
+// will encode into data which decodes without error, and that
+// encoding is stable once the data has been round tripped.
+//
+// The first round trip can legitimately change the data, as
+// values of different types, such as uint(1) and int(1), are
+// encoded in the same way, and so map keys can be merged.
+func FuzzRoundTrip(f *testing.F) {
+
+	fuzzSeeds(f)
+
+	trip := func(t *testing.T, b []byte) []byte {
+		var v interface{}
+		dec := NewDecoderBytes(b).Options(fuzzed)
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("can't decode %x: %v", b, err)
+		}
+		if err := dec.Decode(&v); err != io.EOF {
+			t.Fatalf("found trailing data in %x", b)
+		}
+		buf := bytes.NewBuffer(nil)
+		if err := NewEncoder(buf).Options(fuzzed).Encode(v); err != nil {
+			t.Fatalf("can't encode %#v decoded from %x: %v", v, b, err)
+		}
+		return buf.Bytes()
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+
+		var v interface{}
+
+		if err := NewDecoderBytes(b).Options(fuzzed).Decode(&v); err != nil {
+			return
+		}
+
+		fst := bytes.NewBuffer(nil)
+		if err := NewEncoder(fst).Options(fuzzed).Encode(v); err != nil {
+			t.Fatalf("can't encode %#v decoded from %x: %v", v, b, err)
+		}
+
+		snd := trip(t, fst.Bytes())
+		if trd := trip(t, snd); !bytes.Equal(snd, trd) {
+			t.Fatalf("round trip of %x produced %x then %x", b, snd, trd)
+		}
+
+	})
+
+}
+
+// FuzzCanonical checks that any input can be checked for
+// canonical form without panicking, and that a value which
+// is in canonical form, and which can be held in a Go value,
+// decodes and encodes to the same bytes. The data of a Corker is opaque, so custom types are decoded
+// without a registry, and are encoded again as they were read.
+func FuzzCanonical(f *testing.F) {
+
+	fuzzSeeds(f)
+
+	h := &Handle{Canonical: true, MaxDepth: 100, MaxTotalBytes: 1 << 20, Registry: NewRegistry()}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+
+		if !IsCanonical(b) {
+			return
+		}
+
+		var v interface{}
+		if err := NewDecoderBytes(b).Options(h).Decode(&v); err != nil {
+			return
+		}
+
+		var o []byte
+		if err := NewEncoderBytes(&o).Options(h).Encode(v); err != nil {
+			t.Fatalf("can't encode %#v decoded from %x: %v", v, b, err)
+		}
+
+		if !bytes.Equal(o, b) {
+			t.Fatalf("canonical %x encoded as %x", b, o)
+		}
+
+	})
+
+}
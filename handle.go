@@ -19,6 +19,48 @@ type Handle struct {
 	// being encoded into CORK. This guarantees that the same
 	// input data is always encoded into the same binary data.
 	SortMaps bool
+
+	// StructAsArray specifies whether structs should be
+	// encoded as arrays of their field values, in the order
+	// of the fields in the struct, instead of as maps keyed
+	// by field name. Both forms can always be decoded.
+	StructAsArray bool
+
+	// FullPrecisionInts specifies whether integers should be
+	// encoded with the fixed width of their type, instead of
+	// in as few bytes as possible, so that they are decoded
+	// into the same type when decoding into a nil interface.
+	// As an int or uint has no fixed width, they are encoded
+	// as an int64 or uint64.
+	FullPrecisionInts bool
+
+	// FramedSelfers specifies whether Selfer values should be
+	// encoded with their length, in the same form as a Corker,
+	// so that they can be skipped by a reader which does not
+	// know the type. Both forms can always be decoded.
+	FramedSelfers bool
+
+	// Canonical specifies whether values should be encoded
+	// in their canonical form, so that equal values always
+	// encode to the same bytes, and whether a Decoder should
+	// reject any value which is not in its canonical form.
+	// Maps and structs are written with their entries sorted
+	// by their encoded keys, integers are written in as few
+	// bytes as possible, every NaN is written with the same
+	// bits, negative zero is written as zero, Raw values are
+	// rewritten into canonical form, and Selfers are written
+	// with their length, and their contents in canonical form,
+	// so a Selfer must write values which can be read back,
+	// and can not use EncodeByte. Indefinite-length arrays
+	// and maps can not be written in canonical form.
+	Canonical bool
+
+	// Coercion specifies how numbers are converted when they
+	// are decoded into a variable of a different numeric type.
+	//
+	// If not specified, we use CoerceStrict
+	Coercion Coercion
+
 	// ArrType specifies the type of slice to use when decoding
 	// into a nil interface during schema-less decoding of a
 	// slice in the stream.
@@ -32,4 +74,128 @@ type Handle struct {
 	//
 	// If not specified, we use map[interface{}]interface{}
 	MapType interface{}
+
+	// Registry specifies the registry of Corker and Selfer
+	// types to use when decoding custom types into a nil
+	// interface during schema-less decoding.
+	//
+	// If not specified, we use DefaultRegistry
+	Registry *Registry
+
+	// MaxDepth specifies the maximum depth of nested arrays,
+	// maps, and self-describing values when decoding.
+	//
+	// If not specified, we use 10000
+	MaxDepth int
+
+	// MaxArrayLen specifies the maximum number of elements
+	// in an array when decoding. Indefinite-length arrays
+	// are limited only by MaxTotalBytes.
+	//
+	// If not specified, there is no limit
+	MaxArrayLen int
+
+	// MaxMapLen specifies the maximum number of key-value
+	// pairs in a map when decoding. Indefinite-length maps
+	// are limited only by MaxTotalBytes.
+	//
+	// If not specified, there is no limit
+	MaxMapLen int
+
+	// MaxStringLen specifies the maximum length in bytes
+	// of a string when decoding.
+	//
+	// If not specified, there is no limit
+	MaxStringLen int
+
+	// MaxBinLen specifies the maximum length in bytes of
+	// binary data, or of a custom type, when decoding.
+	//
+	// If not specified, there is no limit
+	MaxBinLen int
+
+	// MaxTotalBytes specifies the maximum number of bytes
+	// which can be read from the stream by a Decoder.
+	//
+	// If not specified, there is no limit
+	MaxTotalBytes int
+}
+
+// Coercion specifies which conversions are allowed when
+// a number is decoded into a different numeric type. The
+// decode fails if a number is out of range of the variable.
+type Coercion int
+
+const (
+	// CoerceStrict decodes signed integers only into signed
+	// integers, unsigned integers only into unsigned integers,
+	// and floats only into floats at least as wide as the value.
+	CoerceStrict Coercion = iota
+	// CoerceLossless also converts between signed, unsigned,
+	// and float types, and narrows a float64 into a float32,
+	// as long as the value can be represented exactly.
+	CoerceLossless
+	// CoerceLenient also converts values which can not be
+	// represented exactly, truncating floats towards zero
+	// when decoding into an integer, and otherwise rounding
+	// to the nearest float.
+	CoerceLenient
+)
+
+const defaultMaxDepth = 10000
+
+func (h *Handle) registry() *Registry {
+	if h == nil || h.Registry == nil {
+		return DefaultRegistry
+	}
+	return h.Registry
+}
+
+func (h *Handle) coercion() Coercion {
+	if h == nil {
+		return CoerceStrict
+	}
+	return h.Coercion
+}
+
+func (h *Handle) maxDepth() int {
+	if h == nil || h.MaxDepth <= 0 {
+		return defaultMaxDepth
+	}
+	return h.MaxDepth
+}
+
+func (h *Handle) maxArrayLen() int {
+	if h == nil {
+		return 0
+	}
+	return h.MaxArrayLen
+}
+
+func (h *Handle) maxMapLen() int {
+	if h == nil {
+		return 0
+	}
+	return h.MaxMapLen
+}
+
+func (h *Handle) maxStringLen() int {
+	if h == nil {
+		return 0
+	}
+	return h.MaxStringLen
+}
+
+func (h *Handle) maxBinLen() int {
+	if h == nil {
+		return 0
+	}
+	return h.MaxBinLen
+}
+
+func (h *Handle) maxTotalBytes() int {
+	if h == nil {
+		return 0
+	}
+	return h.MaxTotalBytes
 }
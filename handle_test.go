@@ -317,6 +317,24 @@ func TestOptions(t *testing.T) {
 		So(tmp, ShouldResemble, val)
 	})
 
+	Convey("Can sort map keys which encode the same", t, func() {
+		var val = map[interface{}]interface{}{int(1): "b", uint(1): "a", int8(1): "c"}
+		var ptr = map[*Corked]int{{Name: "test"}: 2, {Name: "test"}: 1}
+		var opt = &Handle{SortMaps: true}
+		for i := 0; i < 50; i++ {
+			var buf []byte
+			NewEncoderBytes(&buf).Options(opt).Encode(val)
+			So(buf, ShouldResemble, []byte{211, 1, 129, 97, 1, 129, 98, 1, 129, 99})
+		}
+		for i := 0; i < 50; i++ {
+			var buf, one []byte
+			NewEncoderBytes(&buf).Options(opt).Encode(ptr)
+			NewEncoderBytes(&one).Options(opt).Encode(ptr)
+			So(buf, ShouldResemble, one)
+			So(buf[len(buf)-1], ShouldEqual, 2)
+		}
+	})
+
 	Convey("Can create reflect map type", t, func() {
 		var tmp interface{}
 		var buf []byte
@@ -327,5 +345,12 @@ func TestOptions(t *testing.T) {
 		NewDecoderBytes(buf).Options(opt).Decode(&tmp)
 		So(tmp, ShouldResemble, val)
 	})
+	Convey("Pooled coders forget their options when reset", t, func() {
+		var buf []byte
+		NewDecoderBytesFromPool(nil).Options(&Handle{MaxArrayLen: 1}).Reset()
+		NewEncoderBytesFromPool(&buf).Options(&Handle{FullPrecisionInts: true}).Reset()
+		So(Encode(1), ShouldHaveLength, 1)
+		So(Decode(Encode([]int{1, 2, 3})), ShouldResemble, []interface{}{1, 2, 3})
+	})
 
 }
@@ -0,0 +1,141 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package key
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// maxDepth is the deepest that arrays can be nested in a key
+// which is being decoded, so that a hostile key can not exhaust
+// the stack.
+const maxDepth = 10000
+
+type decoder struct {
+	b []byte
+	n int
+	d int
+}
+
+// Decode decodes a key into the tuple of values in it. It returns
+// an error if arrays are nested more than 10000 deep in the key.
+func Decode(src []byte) (vals []interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			vals, err = nil, r.(error)
+		}
+	}()
+	d := &decoder{b: src}
+	for d.n < len(d.b) {
+		vals = append(vals, d.value())
+	}
+	return vals, nil
+}
+
+func (d *decoder) value() interface{} {
+	switch b := d.next(1)[0]; {
+	case b == cNil:
+		return nil
+	case b == cFalse:
+		return false
+	case b == cTrue:
+		return true
+	case b >= cInt-9 && b < cInt:
+		n := int(cInt - 1 - b)
+		return int64(d.uint(n) | ^mask(n))
+	case b >= cInt && b <= cInt+8:
+		u := d.uint(int(b - cInt))
+		if u > math.MaxInt64 {
+			return u
+		}
+		return int64(u)
+	case b == cFloat:
+		u := d.uint(8)
+		if u>>63 == 1 {
+			u &^= 1 << 63
+		} else {
+			u = ^u
+		}
+		return math.Float64frombits(u)
+	case b == cTime:
+		s := int64(d.uint(8) ^ 1<<63)
+		return time.Unix(s, int64(d.uint(4))).UTC()
+	case b == cBin:
+		return d.text()
+	case b == cStr:
+		return string(d.text())
+	case b == cArr:
+		if d.d++; d.d > maxDepth {
+			panic(fmt.Errorf("key: arrays nested deeper than %d (offset %d)", maxDepth, d.n-1))
+		}
+		v := []interface{}{}
+		for d.peek() != cEnd {
+			v = append(v, d.value())
+		}
+		d.n++
+		d.d--
+		return v
+	default:
+		panic(fmt.Errorf("key: can't decode 0x%02X (offset %d)", b, d.n-1))
+	}
+}
+
+func (d *decoder) peek() byte {
+	if d.n >= len(d.b) {
+		panic(fmt.Errorf("key: %w (offset %d)", io.ErrUnexpectedEOF, d.n))
+	}
+	return d.b[d.n]
+}
+
+func (d *decoder) next(l int) []byte {
+	if len(d.b)-d.n < l {
+		panic(fmt.Errorf("key: %w (offset %d)", io.ErrUnexpectedEOF, len(d.b)))
+	}
+	d.n += l
+	return d.b[d.n-l : d.n]
+}
+
+// uint reads an n-byte big-endian unsigned integer.
+func (d *decoder) uint(n int) (u uint64) {
+	for _, c := range d.next(n) {
+		u = u<<8 | uint64(c)
+	}
+	return
+}
+
+// text reads binary data which was written by appendBytes.
+func (d *decoder) text() []byte {
+	v := []byte{}
+	for {
+		c := d.next(1)[0]
+		switch {
+		case c != cEnd:
+			v = append(v, c)
+		case d.n < len(d.b) && d.b[d.n] == cEsc:
+			v = append(v, cEnd)
+			d.n++
+		default:
+			return v
+		}
+	}
+}
+
+// mask returns a mask of the lowest n bytes of a uint64.
+func mask(n int) uint64 {
+	return ^uint64(0) >> (64 - 8*n)
+}
@@ -0,0 +1,209 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package key
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"reflect"
+	"time"
+)
+
+// Encode encodes the tuple vals into a key.
+func Encode(vals ...interface{}) ([]byte, error) {
+	return Append(nil, vals...)
+}
+
+// Append appends the key for the tuple vals to dst, so that
+// a key can be built up from several tuples in turn.
+func Append(dst []byte, vals ...interface{}) ([]byte, error) {
+	var err error
+	for _, v := range vals {
+		if dst, err = appendValue(dst, v); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+func appendValue(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, cNil), nil
+	case bool:
+		if v {
+			return append(b, cTrue), nil
+		}
+		return append(b, cFalse), nil
+	case int:
+		return appendInt(b, int64(v)), nil
+	case int8:
+		return appendInt(b, int64(v)), nil
+	case int16:
+		return appendInt(b, int64(v)), nil
+	case int32:
+		return appendInt(b, int64(v)), nil
+	case int64:
+		return appendInt(b, v), nil
+	case uint:
+		return appendUint(b, uint64(v)), nil
+	case uint8:
+		return appendUint(b, uint64(v)), nil
+	case uint16:
+		return appendUint(b, uint64(v)), nil
+	case uint32:
+		return appendUint(b, uint64(v)), nil
+	case uint64:
+		return appendUint(b, v), nil
+	case float32:
+		return appendFloat(b, float64(v)), nil
+	case float64:
+		return appendFloat(b, v), nil
+	case time.Time:
+		return appendTime(b, v), nil
+	case []byte:
+		return appendBytes(append(b, cBin), v), nil
+	case string:
+		return appendString(append(b, cStr), v), nil
+	case []interface{}:
+		var err error
+		b = append(b, cArr)
+		for _, x := range v {
+			if b, err = appendValue(b, x); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, cEnd), nil
+	}
+	return appendReflect(b, reflect.ValueOf(v))
+}
+
+// appendReflect encodes named types, and arrays and slices
+// of any element type, by the kind of their underlying type.
+func appendReflect(b []byte, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return appendValue(b, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendInt(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return appendUint(b, v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return appendFloat(b, v.Float()), nil
+	case reflect.String:
+		return appendString(append(b, cStr), v.String()), nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return append(b, cNil), nil
+		}
+		return appendValue(b, v.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			x := make([]byte, v.Len())
+			for i := range x {
+				x[i] = byte(v.Index(i).Uint())
+			}
+			return appendBytes(append(b, cBin), x), nil
+		}
+		var err error
+		b = append(b, cArr)
+		for i := 0; i < v.Len(); i++ {
+			if b, err = appendValue(b, v.Index(i).Interface()); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, cEnd), nil
+	}
+	return nil, fmt.Errorf("key: can't encode %s", v.Type())
+}
+
+// appendInt writes a negative integer as a type byte below cInt,
+// which is lower the more bytes are needed to hold its magnitude,
+// followed by the complement of those bytes, so that integers of
+// a larger magnitude sort first.
+func appendInt(b []byte, i int64) []byte {
+	if i >= 0 {
+		return appendUint(b, uint64(i))
+	}
+	n := size(^uint64(i))
+	return appendBig(append(b, cInt-1-byte(n)), uint64(i), n)
+}
+
+// appendUint writes a non-negative integer as a type byte above
+// cInt, which is higher the more bytes are needed to hold it,
+// followed by those bytes.
+func appendUint(b []byte, u uint64) []byte {
+	n := size(u)
+	return appendBig(append(b, cInt+byte(n)), u, n)
+}
+
+// appendFloat writes the bits of a float with the sign bit set if
+// it is positive, or with all bits inverted if it is negative. The
+// negative zero is written as zero, as the two are equal.
+func appendFloat(b []byte, f float64) []byte {
+	if f == 0 {
+		f = 0
+	}
+	u := math.Float64bits(f)
+	if u>>63 == 1 {
+		u = ^u
+	} else {
+		u |= 1 << 63
+	}
+	return appendBig(append(b, cFloat), u, 8)
+}
+
+// appendTime writes the seconds since the epoch with the sign bit
+// inverted, so that times before the epoch sort first, followed by
+// the nanoseconds within the second.
+func appendTime(b []byte, t time.Time) []byte {
+	b = appendBig(append(b, cTime), uint64(t.Unix())^1<<63, 8)
+	return appendBig(b, uint64(t.Nanosecond()), 4)
+}
+
+// appendBytes writes binary data, escaping any cEnd bytes,
+// and terminates it with cEnd.
+func appendBytes(b []byte, v []byte) []byte {
+	for _, c := range v {
+		if b = append(b, c); c == cEnd {
+			b = append(b, cEsc)
+		}
+	}
+	return append(b, cEnd)
+}
+
+// appendString writes a string in the same way as appendBytes.
+func appendString(b []byte, v string) []byte {
+	for i := 0; i < len(v); i++ {
+		if b = append(b, v[i]); v[i] == cEnd {
+			b = append(b, cEsc)
+		}
+	}
+	return append(b, cEnd)
+}
+
+// appendBig writes the lowest n bytes of u in big-endian order.
+func appendBig(b []byte, u uint64, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(u>>(8*i)))
+	}
+	return b
+}
+
+// size returns the number of bytes needed to hold u.
+func size(u uint64) int {
+	return (bits.Len64(u) + 7) / 8
+}
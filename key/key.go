@@ -0,0 +1,83 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package key encodes tuples of values into keys for use in an ordered key-value
+store. Unlike the CORK format, the encoding is order-preserving, so that keys
+compared with bytes.Compare are ordered in the same way as the values in them.
+
+Tuples can hold nil, bool, signed and unsigned integers, floats, strings, byte
+slices, times, and nested arrays of any of these. Tuples are ordered element by
+element, so a tuple sorts before any longer tuple which it is a prefix of. Values
+of different types are ordered by their type, in the following order:
+
+	nil
+	false
+	true
+	integers
+	floats
+	times
+	[]byte
+	string
+	arrays
+
+All integers are ordered together by their value, whatever their size or their
+signedness, and are decoded as an int64, or as a uint64 if they are too large.
+Floats are decoded as a float64, and times are decoded in UTC. Arrays are
+decoded as []interface{}.
+
+A key which begins with the encoding of a tuple can be found by scanning the
+range returned by Range, or from a prefix up to the key returned by PrefixEnd.
+*/
+package key
+
+const (
+	cEnd   byte = 0x00
+	cNil   byte = 0x01
+	cFalse byte = 0x02
+	cTrue  byte = 0x03
+	cInt   byte = 0x20 // 0x17 -> 0x28
+	cFloat byte = 0x30
+	cTime  byte = 0x40
+	cBin   byte = 0x50
+	cStr   byte = 0x60
+	cArr   byte = 0x70
+)
+
+// Escaped bytes within strings and byte slices are written
+// as cEnd followed by cEsc, so that cEnd followed by any
+// other byte marks the end of the value.
+const cEsc byte = 0xFF
+
+// Range returns the start and end of the range of keys which begin
+// with the encoding of the tuple vals, including the key for vals.
+func Range(vals ...interface{}) (beg, end []byte, err error) {
+	if beg, err = Encode(vals...); err != nil {
+		return nil, nil, err
+	}
+	return beg, PrefixEnd(beg), nil
+}
+
+// PrefixEnd returns the first key which is greater than every key
+// which begins with prefix, or nil if there is no such key.
+func PrefixEnd(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xFF {
+			end := append([]byte(nil), prefix[:i+1]...)
+			end[i]++
+			return end
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package key
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Name string
+
+func enc(vals ...interface{}) []byte {
+	b, err := Encode(vals...)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestKey(t *testing.T) {
+
+	now := time.Now().UTC()
+
+	Convey("Tuples will encode and decode", t, func() {
+		val := []interface{}{
+			nil, true, false,
+			int64(0), int64(-1), int64(math.MinInt64), int64(math.MaxInt64), uint64(math.MaxUint64),
+			1.5, math.Inf(-1), now, []byte{0, 1, 0xFF}, "a\x00b",
+			[]interface{}{int64(1), []interface{}{}, "c"},
+		}
+		out, err := Decode(enc(val...))
+		So(err, ShouldBeNil)
+		So(out, ShouldResemble, val)
+	})
+
+	Convey("Values decode into the widest type of their kind", t, func() {
+		out, err := Decode(enc(int8(-3), uint16(3), float32(0.5), Name("n"), []string{"a"}, [2]byte{1, 2}, &now))
+		So(err, ShouldBeNil)
+		So(out, ShouldResemble, []interface{}{int64(-3), int64(3), 0.5, "n", []interface{}{"a"}, []byte{1, 2}, now})
+	})
+
+	Convey("Keys will sort in the same order as their values", t, func() {
+		vals := [][]interface{}{
+			{},
+			{nil},
+			{false},
+			{true},
+			{int64(math.MinInt64)},
+			{-1 << 32},
+			{-65536},
+			{-257},
+			{-256},
+			{-255},
+			{-2},
+			{-1},
+			{0},
+			{1},
+			{255},
+			{256},
+			{uint64(math.MaxUint64)},
+			{math.Inf(-1)},
+			{-2.5},
+			{-1e-300},
+			{0.0},
+			{1e-300},
+			{2.5},
+			{math.Inf(1)},
+			{time.Unix(-1, 0)},
+			{time.Unix(0, 0)},
+			{time.Unix(0, 1)},
+			{[]byte{}},
+			{[]byte{0}},
+			{[]byte{0, 0}},
+			{[]byte{1}},
+			{""},
+			{"", nil},
+			{"", "a"},
+			{"\x00"},
+			{"a"},
+			{"a", 1},
+			{"a", 2},
+			{"a\x00"},
+			{"a\x00b"},
+			{"a\x01"},
+			{"ab"},
+			{"b"},
+			{[]interface{}{}},
+			{[]interface{}{nil}},
+			{[]interface{}{1}},
+			{[]interface{}{1}, 1},
+			{[]interface{}{1, 2}},
+			{[]interface{}{"a"}},
+		}
+		for i := 1; i < len(vals); i++ {
+			So(bytes.Compare(enc(vals[i-1]...), enc(vals[i]...)), ShouldEqual, -1)
+		}
+	})
+
+	Convey("Negative zero will sort equal to zero", t, func() {
+		So(enc(math.Copysign(0, -1)), ShouldResemble, enc(0.0))
+	})
+
+	Convey("Keys can be appended to a prefix", t, func() {
+		b, err := Append(enc("users"), 42)
+		So(err, ShouldBeNil)
+		So(b, ShouldResemble, enc("users", 42))
+	})
+
+	Convey("Ranges will contain keys which begin with the tuple", t, func() {
+		beg, end, err := Range("users", 1)
+		So(err, ShouldBeNil)
+		for _, k := range [][]byte{enc("users", 1), enc("users", 1, "a"), enc("users", 1, []interface{}{2})} {
+			So(bytes.Compare(beg, k), ShouldBeLessThanOrEqualTo, 0)
+			So(bytes.Compare(k, end), ShouldEqual, -1)
+		}
+		for _, k := range [][]byte{enc("users", 0), enc("users", 2), enc("users"), enc("users\x00", 1), enc("usersa")} {
+			So(bytes.Compare(beg, k) <= 0 && bytes.Compare(k, end) < 0, ShouldBeFalse)
+		}
+	})
+
+	Convey("Prefixes will end after their last byte which can be incremented", t, func() {
+		So(PrefixEnd([]byte{1, 2}), ShouldResemble, []byte{1, 3})
+		So(PrefixEnd([]byte{1, 0xFF, 0xFF}), ShouldResemble, []byte{2})
+		So(PrefixEnd([]byte{0xFF}), ShouldBeNil)
+		So(PrefixEnd(nil), ShouldBeNil)
+	})
+
+	Convey("Unsupported values will fail to encode", t, func() {
+		_, err := Encode(1, map[string]int{})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "key: can't encode map[string]int")
+		_, err = Encode([]interface{}{struct{}{}})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Invalid keys will fail to decode", t, func() {
+		_, err := Decode([]byte{cStr, 'a'})
+		So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+		_, err = Decode([]byte{cArr, cNil})
+		So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+		_, err = Decode([]byte{cInt + 2, 1})
+		So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+		_, err = Decode([]byte{cNil, cEnd})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "key: can't decode 0x00 (offset 1)")
+	})
+
+	Convey("Deeply nested keys will fail to decode", t, func() {
+		src := append(bytes.Repeat([]byte{cArr}, 10001), bytes.Repeat([]byte{cEnd}, 10001)...)
+		_, err := Decode(src)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "key: arrays nested deeper than 10000 (offset 10000)")
+		vals, err := Decode(src[1 : len(src)-1])
+		So(err, ShouldBeNil)
+		So(vals, ShouldHaveLength, 1)
+	})
+
+}
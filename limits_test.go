@@ -15,7 +15,10 @@
 package cork
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"testing"
 
@@ -79,3 +82,91 @@ func TestLimits(t *testing.T) {
 	})
 
 }
+
+func TestHostile(t *testing.T) {
+
+	limited := func(h *Handle, bit []byte, dst interface{}) *LimitError {
+		var err *LimitError
+		if errors.As(NewDecoderBytes(bit).Options(h).Decode(dst), &err) {
+			return err
+		}
+		return nil
+	}
+
+	nested := func(n int) []byte {
+		return append(bytes.Repeat([]byte{cFixArr + 1}, n), cNil)
+	}
+
+	Convey("Nested values deeper than MaxDepth will fail", t, func() {
+		var tmp interface{}
+		So(limited(&Handle{MaxDepth: 3}, nested(3), &tmp), ShouldBeNil)
+		So(limited(&Handle{MaxDepth: 3}, nested(4), &tmp), ShouldResemble, &LimitError{Limit: "MaxDepth", Max: 3})
+	})
+
+	Convey("Nested values will be limited by default", t, func() {
+		var tmp interface{}
+		So(limited(nil, nested(20000), &tmp), ShouldResemble, &LimitError{Limit: "MaxDepth", Max: 10000})
+	})
+
+	Convey("Skipped values will be limited by MaxDepth", t, func() {
+		var tmp struct{ A int }
+		var bit = append([]byte{cFixMap + 1, cFixStr + 1, 'B'}, nested(4)...)
+		So(limited(&Handle{MaxDepth: 3}, bit, &tmp), ShouldResemble, &LimitError{Limit: "MaxDepth", Max: 3})
+	})
+
+	Convey("Arrays and maps longer than the limit will fail", t, func() {
+		var arr []int
+		var mAp map[string]int
+		So(limited(&Handle{MaxArrayLen: 2}, Encode([]int{1, 2}), &arr), ShouldBeNil)
+		So(limited(&Handle{MaxArrayLen: 2}, Encode([]int{1, 2, 3}), &arr), ShouldResemble, &LimitError{Limit: "MaxArrayLen", Max: 2})
+		So(limited(&Handle{MaxMapLen: 1}, Encode(map[string]int{"a": 1, "b": 2}), &mAp), ShouldResemble, &LimitError{Limit: "MaxMapLen", Max: 1})
+	})
+
+	Convey("Strings and binary data longer than the limit will fail", t, func() {
+		var str string
+		var bin []byte
+		var tmp interface{}
+		So(limited(&Handle{MaxStringLen: 4}, Encode("test"), &str), ShouldBeNil)
+		So(limited(&Handle{MaxStringLen: 4}, Encode("tests"), &str), ShouldResemble, &LimitError{Limit: "MaxStringLen", Max: 4})
+		So(limited(&Handle{MaxBinLen: 4}, Encode([]byte("tests")), &bin), ShouldResemble, &LimitError{Limit: "MaxBinLen", Max: 4})
+		So(limited(&Handle{MaxBinLen: 4}, Encode(&Corked{Name: "test"}), &tmp), ShouldResemble, &LimitError{Limit: "MaxBinLen", Max: 4})
+	})
+
+	Convey("Streams longer than MaxTotalBytes will fail", t, func() {
+		var tmp interface{}
+		So(limited(&Handle{MaxTotalBytes: 7}, Encode([]string{"one", "two"}), &tmp), ShouldResemble, &LimitError{Limit: "MaxTotalBytes", Max: 7})
+		So(limited(&Handle{MaxTotalBytes: 9}, Encode([]string{"one", "two"}), &tmp), ShouldBeNil)
+	})
+
+	Convey("Huge lengths will not be allocated up front", t, func() {
+		var arr []interface{}
+		var mAp map[string]interface{}
+		var str string
+		for _, tst := range []struct {
+			bit []byte
+			dst interface{}
+		}{
+			{[]byte{cArr, cUint32, 0x7F, 0xFF, 0xFF, 0xFF, cNil}, &arr},
+			{[]byte{cMap, cUint32, 0x7F, 0xFF, 0xFF, 0xFF, cFixStr + 1, 'a'}, &mAp},
+			{[]byte{cStr32, 0x7F, 0xFF, 0xFF, 0xFF, 'a'}, &str},
+		} {
+			So(errors.Is(NewDecoderBytes(tst.bit).Decode(tst.dst), io.ErrUnexpectedEOF), ShouldBeTrue)
+			So(errors.Is(NewDecoder(bytes.NewReader(tst.bit)).Decode(tst.dst), io.ErrUnexpectedEOF), ShouldBeTrue)
+		}
+	})
+
+	Convey("Lengths which overflow an int will fail", t, func() {
+		var arr []interface{}
+		var str string
+		So(errors.Is(NewDecoderBytes([]byte{cArr, cUint64, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}).Decode(&arr), overflow), ShouldBeTrue)
+		So(errors.Is(NewDecoderBytes([]byte{cStr64, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}).Decode(&str), overflow), ShouldBeTrue)
+	})
+
+	Convey("Large values will be read from a stream in chunks", t, func() {
+		var str string
+		var val = string(bytes.Repeat([]byte("test"), chunk))
+		So(NewDecoder(bytes.NewReader(Encode(val))).Decode(&str), ShouldBeNil)
+		So(str, ShouldEqual, val)
+	})
+
+}
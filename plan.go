@@ -0,0 +1,79 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"reflect"
+)
+
+// plan holds the compiled encoder and decoder for a type.
+// Plans are built once for each type, the first time that
+// the type is encoded or decoded, so that the kind of the
+// type, the interfaces which it implements, and the fields
+// of structs, are not discovered again for every value.
+// The plans for any element, key, or field types are
+// resolved when the plan is built, so that encoding a
+// value only looks up the cache once, at the top level.
+type plan struct {
+	enc func(w *Writer, v reflect.Value)
+	dec func(r *Reader, v reflect.Value)
+}
+
+// Plan returns the compiled plan for a type, building
+// it, and the plans of any types within it, if needed.
+func (c *cache) Plan(t reflect.Type) *plan {
+	c.pl.RLock()
+	p := c.p[t]
+	c.pl.RUnlock()
+	if p != nil {
+		return p
+	}
+	c.pl.Lock()
+	defer c.pl.Unlock()
+	return c.plan(t)
+}
+
+// plan builds the plan for a type whilst the cache is
+// locked. Recursive types will find their own plan in
+// the cache before it is complete, which is fine, as
+// the encoder and decoder are only read when called.
+func (c *cache) plan(t reflect.Type) *plan {
+	if p := c.p[t]; p != nil {
+		return p
+	}
+	p := &plan{}
+	c.p[t] = p
+	p.enc = c.encoder(t)
+	p.dec = c.decoder(t)
+	return p
+}
+
+// fields returns the fields of a struct type, along with
+// the plans for the value of each field, and for a pointer
+// to each field, which is used to decode into the field
+// when it is addressable, or an error if the tags of the
+// fields are not valid.
+func (c *cache) fields(t reflect.Type) ([]*field, error) {
+	fls, err := newFields(t)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fls {
+		k := t.FieldByIndex(f.indx).Type
+		f.val = c.plan(k)
+		f.ptr = c.plan(reflect.PtrTo(k))
+	}
+	return fls, nil
+}
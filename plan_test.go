@@ -0,0 +1,188 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type planned struct {
+	ID     int
+	Name   string `cork:"name"`
+	Email  string `cork:",omitempty"`
+	Score  float64
+	Tags   []string
+	Counts map[string]int
+	Items  []plannedItem
+	Next   *planned
+}
+
+type plannedItem struct {
+	Key   string
+	Value int64
+	Flag  bool
+}
+
+func plannedValue() *planned {
+	return &planned{
+		ID:     1,
+		Name:   "Tobie",
+		Email:  "info@surrealdb.com",
+		Score:  99.5,
+		Tags:   []string{"one", "two", "three"},
+		Counts: map[string]int{"a": 1, "b": 2},
+		Items: []plannedItem{
+			{Key: "one", Value: 1, Flag: true},
+			{Key: "two", Value: 2},
+			{Key: "three", Value: 3, Flag: true},
+		},
+		Next: &planned{
+			ID:     2,
+			Name:   "Jaime",
+			Tags:   []string{},
+			Counts: map[string]int{},
+			Items:  []plannedItem{},
+		},
+	}
+}
+
+func TestPlans(t *testing.T) {
+
+	Convey("Recursive types can be encoded and decoded", t, func() {
+		var tmp planned
+		val := plannedValue()
+		DecodeInto(Encode(val), &tmp)
+		So(&tmp, ShouldResemble, val)
+	})
+
+	Convey("Plans are built once for each type", t, func() {
+		type once struct{ A int }
+		So(c.Plan(reflect.TypeOf(once{})), ShouldEqual, c.Plan(reflect.TypeOf(once{})))
+	})
+
+	Convey("Plans can be built concurrently", t, func() {
+		type racy struct {
+			A []racy
+			B map[string]*racy
+		}
+		var wg sync.WaitGroup
+		out := make([]*racy, 8)
+		for i := range out {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				out[i] = new(racy)
+				DecodeInto(Encode(racy{A: []racy{{}}, B: map[string]*racy{"a": {}}}), out[i])
+			}(i)
+		}
+		wg.Wait()
+		for _, v := range out {
+			So(v, ShouldResemble, &racy{A: []racy{{A: []racy{}, B: map[string]*racy{}}}, B: map[string]*racy{"a": {A: []racy{}, B: map[string]*racy{}}}})
+		}
+	})
+
+	Convey("Unknown fields are skipped when decoding", t, func() {
+		var tmp plannedItem
+		enc := Encode(map[string]interface{}{"Key": "key", "Other": []int{1, 2}, "Value": 5})
+		So(NewDecoderBytes(enc).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, plannedItem{Key: "key", Value: 5})
+	})
+
+}
+
+func BenchmarkEncodeReflect(b *testing.B) {
+	val := plannedValue()
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = buf[:0]
+		NewEncoderBytes(&buf).Encode(val)
+	}
+}
+
+func BenchmarkDecodeReflect(b *testing.B) {
+	enc := Encode(plannedValue())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var tmp planned
+		NewDecoderBytes(enc).Decode(&tmp)
+	}
+}
+
+// uncached empties the cache of plans, so that the plan of
+// each type is built again, in the same way as each type was
+// inspected for every value before plans were cached.
+func uncached() {
+	c.pl.Lock()
+	c.p = make(map[reflect.Type]*plan)
+	c.pl.Unlock()
+}
+
+func BenchmarkEncodeReflectUncached(b *testing.B) {
+	val := plannedValue()
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		uncached()
+		buf = buf[:0]
+		NewEncoderBytes(&buf).Encode(val)
+	}
+}
+
+func BenchmarkDecodeReflectUncached(b *testing.B) {
+	enc := Encode(plannedValue())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var tmp planned
+		uncached()
+		NewDecoderBytes(enc).Decode(&tmp)
+	}
+}
+
+func BenchmarkEncodeReflectSlice(b *testing.B) {
+	val := make([]plannedItem, 1000)
+	for i := range val {
+		val[i] = plannedItem{Key: "key", Value: int64(i), Flag: i%2 == 0}
+	}
+	buf := make([]byte, 0, 1<<16)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = buf[:0]
+		NewEncoderBytes(&buf).Encode(val)
+	}
+}
+
+func BenchmarkDecodeReflectSlice(b *testing.B) {
+	val := make([]plannedItem, 1000)
+	for i := range val {
+		val[i] = plannedItem{Key: "key", Value: int64(i), Flag: i%2 == 0}
+	}
+	enc := Encode(val)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var tmp []plannedItem
+		NewDecoderBytes(enc).Decode(&tmp)
+	}
+}
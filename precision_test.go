@@ -0,0 +1,98 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Widths struct {
+	I8  int8
+	I16 int16
+	I32 int32
+	I64 int64
+	U8  uint8
+	U16 uint16
+	U32 uint32
+	U64 uint64
+	Key int `cork:"key,key=1"`
+}
+
+func TestFullPrecision(t *testing.T) {
+
+	h := &Handle{FullPrecisionInts: true}
+
+	enc := func(src interface{}) (dst []byte) {
+		NewEncoderBytes(&dst).Options(h).Encode(src)
+		return
+	}
+
+	dec := func(src []byte) (dst interface{}) {
+		NewDecoderBytes(src).Options(h).Decode(&dst)
+		return
+	}
+
+	Convey("Sized integers are encoded with their full width", t, func() {
+		So(enc(int8(1)), ShouldResemble, []byte{cInt8, 1})
+		So(enc(int16(1)), ShouldResemble, []byte{cInt16, 0, 1})
+		So(enc(int32(1)), ShouldResemble, []byte{cInt32, 0, 0, 0, 1})
+		So(enc(int64(1)), ShouldResemble, []byte{cInt64, 0, 0, 0, 0, 0, 0, 0, 1})
+		So(enc(uint8(1)), ShouldResemble, []byte{cUint8, 1})
+		So(enc(uint16(1)), ShouldResemble, []byte{cUint16, 0, 1})
+		So(enc(uint32(1)), ShouldResemble, []byte{cUint32, 0, 0, 0, 1})
+		So(enc(uint64(1)), ShouldResemble, []byte{cUint64, 0, 0, 0, 0, 0, 0, 0, 1})
+	})
+
+	Convey("Integers without a width are encoded as 64 bits", t, func() {
+		So(enc(int(1)), ShouldResemble, []byte{cInt64, 0, 0, 0, 0, 0, 0, 0, 1})
+		So(enc(uint(1)), ShouldResemble, []byte{cUint64, 0, 0, 0, 0, 0, 0, 0, 1})
+		So(dec(enc(int(-1))), ShouldEqual, int64(-1))
+		So(dec(enc(uint(1))), ShouldEqual, uint64(1))
+	})
+
+	Convey("Sized integers decode into the same type", t, func() {
+		val := []interface{}{int8(math.MinInt8), int16(math.MinInt16), int32(math.MinInt32), int64(math.MinInt64), uint8(math.MaxUint8), uint16(math.MaxUint16), uint32(math.MaxUint32), uint64(math.MaxUint64), int8(0), uint64(0)}
+		So(dec(enc(val)), ShouldResemble, val)
+		So(dec(enc(map[string]interface{}{"a": int16(5), "b": []interface{}{uint32(6)}})), ShouldResemble, map[interface{}]interface{}{"a": int16(5), "b": []interface{}{uint32(6)}})
+	})
+
+	Convey("Struct fields keep their width", t, func() {
+		var tmp Widths
+		val := Widths{-1, -2, -3, -4, 5, 6, 7, 8, 9}
+		So(dec(enc(val)), ShouldResemble, map[interface{}]interface{}{"I8": int8(-1), "I16": int16(-2), "I32": int32(-3), "I64": int64(-4), "U8": uint8(5), "U16": uint16(6), "U32": uint32(7), "U64": uint64(8), 1: int64(9)})
+		So(NewDecoderBytes(enc(val)).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Struct keys are not encoded with their full width", t, func() {
+		So(enc(struct {
+			A int `cork:"a,key=1"`
+		}{2}), ShouldResemble, []byte{cFixMap + 1, 1, cInt64, 0, 0, 0, 0, 0, 0, 0, 2})
+	})
+
+	Convey("Sorted map keys are encoded with their full width", t, func() {
+		var dst []byte
+		NewEncoderBytes(&dst).Options(&Handle{FullPrecisionInts: true, SortMaps: true}).Encode(map[int16]bool{2: true, 1: false})
+		So(dst, ShouldResemble, []byte{cFixMap + 2, cInt16, 0, 1, cFalse, cInt16, 0, 2, cTrue})
+	})
+
+	Convey("Integers decode as int or uint without the option", t, func() {
+		So(Decode(enc([]interface{}{int8(1), uint16(1)})), ShouldResemble, []interface{}{int(1), uint(1)})
+	})
+
+}
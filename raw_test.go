@@ -0,0 +1,86 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Envelope struct {
+	Kind string
+	Body Raw
+	Meta *Raw
+}
+
+func TestRaw(t *testing.T) {
+
+	Convey("Raw will encode and decode", t, func() {
+		var tmp Raw
+		var val = Raw{cFixArr + 0x02, cTrue, cFalse}
+		var enc = Encode(val)
+		DecodeInto(enc, &tmp)
+		So(enc, ShouldResemble, []byte(val))
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("Empty Raw will encode as nil", t, func() {
+		var val Raw
+		So(Encode(val), ShouldResemble, []byte{cNil})
+	})
+
+	Convey("Raw will capture nil values", t, func() {
+		var tmp Raw
+		DecodeInto([]byte{cNil}, &tmp)
+		So(tmp, ShouldResemble, Raw{cNil})
+	})
+
+	Convey("Raw will capture values without aliasing the source", t, func() {
+		var tmp Raw
+		var bit = Encode("Hello")
+		DecodeInto(bit, &tmp)
+		bit[1] = 'J'
+		So(tmp, ShouldResemble, Raw(Encode("Hello")))
+	})
+
+	Convey("Raw struct fields defer decoding of nested values", t, func() {
+		var tmp Envelope
+		var out Tested
+		var val = Tested{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""}
+		var oth = Tested{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""}
+		var bit = Encode(map[string]interface{}{"Kind": "tested", "Body": val, "Meta": []interface{}{1, "2"}})
+		So(NewDecoderBytes(bit).Decode(&tmp), ShouldBeNil)
+		So(tmp.Kind, ShouldEqual, "tested")
+		So(tmp.Body, ShouldResemble, Raw(Encode(val)))
+		So(*tmp.Meta, ShouldResemble, Raw(Encode([]interface{}{1, "2"})))
+		So(NewDecoderBytes(tmp.Body).Decode(&out), ShouldBeNil)
+		So(out, ShouldResemble, oth)
+	})
+
+	Convey("Raw struct fields are re-encoded verbatim", t, func() {
+		var tmp Envelope
+		var bit = Encode(&Envelope{Kind: "selfed", Body: Raw(Encode(&Selfed{Name: "test"}))})
+		So(NewDecoderBytes(bit).Decode(&tmp), ShouldBeNil)
+		So(Encode(&tmp), ShouldResemble, bit)
+	})
+
+	Convey("Raw values within interfaces are re-encoded verbatim", t, func() {
+		var val = []interface{}{Raw(Encode(&Corked{Name: "test"})), Raw(Encode(1))}
+		var bit = Encode(val)
+		So(bit, ShouldResemble, append(append([]byte{cFixArr + 0x02}, Encode(&Corked{Name: "test"})...), 1))
+	})
+
+}
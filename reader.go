@@ -16,8 +16,10 @@ package cork
 
 import (
 	"encoding/binary"
+	"io"
 	"math"
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/surrealdb/bump"
@@ -27,15 +29,33 @@ import (
 type Reader struct {
 	h *Handle
 	r *bump.Reader
-}
+	b *[]byte
+	t []frame
+	p bool
+	u byte
+	e error
+	n int
+	l byte
+	z int
+	d int
+}
+
+// chunk specifies the size of the chunks in which large
+// values are read from an io.Reader, so that a corrupt
+// length does not cause a huge up-front allocation.
+const chunk = 64 * 1024
 
 func newReader() *Reader {
 	return &Reader{
 		r: bump.NewReader(nil),
+		z: -1,
 	}
 }
 
 func (r *Reader) peekOne() (val byte) {
+	if r.p {
+		return r.u
+	}
 	val, err := r.r.PeekByte()
 	if err != nil {
 		panic(err)
@@ -44,29 +64,94 @@ func (r *Reader) peekOne() (val byte) {
 }
 
 func (r *Reader) readOne() (val byte) {
-	val, err := r.r.ReadByte()
-	if err != nil {
-		panic(err)
+	if r.p {
+		val, r.p = r.u, false
+	} else {
+		var err error
+		if val, err = r.r.ReadByte(); err != nil {
+			panic(err)
+		}
+	}
+	r.n, r.l = r.n+1, val
+	if m := r.h.maxTotalBytes(); m > 0 && r.n > m {
+		panic(&LimitError{Limit: "MaxTotalBytes", Max: m})
+	}
+	if r.b != nil {
+		*r.b = append(*r.b, val)
 	}
 	return val
 }
 
 func (r *Reader) readMany(l int) (val []byte) {
-	val, err := r.r.ReadBytes(l)
+	var err error
+	r.count(l)
+	if r.z < 0 && l > chunk {
+		val, err = r.readChunks(l)
+	} else {
+		val, err = r.r.ReadBytes(l)
+	}
 	if err != nil {
 		panic(err)
 	}
+	r.n += l
+	if r.b != nil {
+		*r.b = append(*r.b, val...)
+	}
 	return val
 }
 
 func (r *Reader) readText(l int) (val string) {
-	val, err := r.r.ReadString(l)
+	var err error
+	r.count(l)
+	if r.z < 0 && l > chunk {
+		var b []byte
+		b, err = r.readChunks(l)
+		val = string(b)
+	} else {
+		val, err = r.r.ReadString(l)
+	}
 	if err != nil {
 		panic(err)
 	}
+	r.n += l
+	if r.b != nil {
+		*r.b = append(*r.b, val...)
+	}
 	return val
 }
 
+// readChunks reads l bytes from an io.Reader in chunks, so
+// that memory is only allocated as the data is received.
+func (r *Reader) readChunks(l int) (val []byte, err error) {
+	for len(val) < l {
+		n := l - len(val)
+		if n > chunk {
+			n = chunk
+		}
+		var b []byte
+		if b, err = r.r.ReadBytes(n); err != nil {
+			return nil, err
+		}
+		val = append(val, b...)
+	}
+	return
+}
+
+// count checks that l more bytes can be read from the
+// stream, without exceeding the end of a byte slice, or
+// the maximum number of bytes set on the Handle.
+func (r *Reader) count(l int) {
+	if l < 0 {
+		panic(overflow)
+	}
+	if m := r.h.maxTotalBytes(); m > 0 && l > m-r.n {
+		panic(&LimitError{Limit: "MaxTotalBytes", Max: m})
+	}
+	if r.z >= 0 && l > r.z-r.n {
+		panic(io.EOF)
+	}
+}
+
 // ---------------------------------------------------------------------------
 
 func (r *Reader) readLen() int {
@@ -83,8 +168,99 @@ func (r *Reader) readLen() int {
 	case b == cUint64:
 		return int(binary.BigEndian.Uint64(r.readMany(8)))
 	default:
-		panic(fail)
+		panic(r.unexpected("uint", nil))
+	}
+}
+
+// readCnt reads the number of items in an array or map,
+// returning -1 if the container is of indefinite length.
+func (r *Reader) readCnt() int {
+	if r.peekOne() == cNil {
+		r.readOne()
+		return -1
+	}
+	if l := r.readLen(); l >= 0 {
+		return l
+	}
+	panic(overflow)
+}
+
+// more reports whether there is another item to be read
+// from an array or map of length s, when i items have
+// already been read. If the container is of indefinite
+// length, then more consumes the break marker at its end.
+func (r *Reader) more(i, s int) bool {
+	switch {
+	case s >= 0 && i < s:
+		return true
+	case s >= 0:
+		r.leave()
+		return false
+	case r.peekOne() != cAlt:
+		return true
+	}
+	r.readOne()
+	if r.peekOne() == cAltBrk {
+		r.readOne()
+		r.leave()
+		return false
+	}
+	r.unread(cAlt)
+	return true
+}
+
+// unread pushes a single byte back onto the stream, so
+// that it is returned by the next call to readOne.
+func (r *Reader) unread(b byte) {
+	if r.b != nil {
+		*r.b = (*r.b)[:len(*r.b)-1]
+	}
+	r.p, r.u, r.n = true, b, r.n-1
+}
+
+// alloc returns the number of items to allocate up front
+// for an array or map of length s. As every item takes at
+// least one byte, this is capped at the number of bytes
+// which remain when reading from a byte slice, or at a
+// fixed size when reading from an io.Reader, so that a
+// corrupt length does not cause a huge allocation.
+func (r *Reader) alloc(s int) int {
+	switch {
+	case s < 0:
+		return 0
+	case r.z >= 0 && s > r.z-r.n:
+		return r.z - r.n
+	case r.z < 0 && s > chunk:
+		return chunk
+	}
+	return s
+}
+
+// enter is called at the start of every array, map, and
+// self-describing value, and checks that the maximum depth
+// of nested values has not been exceeded.
+func (r *Reader) enter() {
+	if r.d++; r.d > r.h.maxDepth() {
+		panic(&LimitError{Limit: "MaxDepth", Max: r.h.maxDepth()})
+	}
+}
+
+// leave is called at the end of every array, map, and
+// self-describing value.
+func (r *Reader) leave() {
+	r.d--
+}
+
+// length checks the length l of a value against the limit
+// max set on the Handle, where a limit of 0 is not applied.
+func (r *Reader) length(l, max int, name string) int {
+	if l < 0 {
+		panic(overflow)
 	}
+	if max > 0 && l > max {
+		panic(&LimitError{Limit: name, Max: max})
+	}
+	return l
 }
 
 func (r *Reader) readLen8() int {
@@ -105,6 +281,55 @@ func (r *Reader) readLen64() int {
 
 // ---------------------------------------------------------------------------
 
+/*
+Decode decodes the next value from the Reader into 'dst', in the same way as
+Decoder.Decode. Unlike the other Decode methods on the Reader, which panic
+when a value can not be decoded, Decode returns an error, so that it can be
+used safely from within a Selfer.
+
+Once an error has occurred, it is retained by the Reader, and is returned from
+any further calls to Decode, and from Err. An error which occurs within a Selfer
+is therefore also returned by the Decode call which is decoding that Selfer.
+
+Example:
+
+	func (s *Selfed) UnmarshalCORK(r *cork.Reader) error {
+		r.Decode(&s.Name)
+		r.Decode(&s.Data)
+		return r.Err()
+	}
+
+*/
+func (r *Reader) Decode(dst interface{}) (err error) {
+	if r.e != nil {
+		return r.e
+	}
+	o, d := r.n, r.d
+	defer func() {
+		if v := recover(); v != nil {
+			r.d = d
+			// Reaching the end of the stream before
+			// the start of a value is not an error
+			// in the value, so we return it as is.
+			if v == io.EOF && r.n == o {
+				r.e = io.EOF
+			} else {
+				r.e = r.error(v, dst)
+			}
+			err = r.e
+		}
+	}()
+	r.DecodeAny(dst)
+	return r.e
+}
+
+// Err returns the first error which occurred when calling Decode.
+func (r *Reader) Err() error {
+	return r.e
+}
+
+// ---------------------------------------------------------------------------
+
 // DecodeBool decodes a boolean value from the Reader.
 func (r *Reader) DecodeBool(v *bool) {
 	switch r.readOne() {
@@ -113,51 +338,70 @@ func (r *Reader) DecodeBool(v *bool) {
 	case cFalse:
 		*v = false
 	default:
-		panic(fail)
+		panic(r.unexpected("bool", v))
 	}
 }
 
-// DecodeByte decodes a byte value from the Reader.
+// DecodeByte reads a single raw byte from the Reader, which
+// was written by EncodeByte. Use DecodeUint8 to decode a
+// uint8 value.
 func (r *Reader) DecodeByte(v *byte) {
 	*v = r.readOne()
 }
 
 // DecodeBytes decodes a byte slice value from the Reader.
 func (r *Reader) DecodeBytes(v *[]byte) {
+	*v = r.readMany(r.decodeBinLen(v))
+}
+
+func (r *Reader) decodeBinLen(v interface{}) int {
+	var l int
 	b := r.readOne()
 	switch {
 	case b >= cFixBin && b <= cFixBin+fixedBin:
-		*v = r.readMany(int(b - cFixBin))
+		l = int(b - cFixBin)
 	case b == cBin8:
-		*v = r.readMany(int(r.readLen8()))
+		l = r.readLen8()
 	case b == cBin16:
-		*v = r.readMany(int(r.readLen16()))
+		l = r.readLen16()
 	case b == cBin32:
-		*v = r.readMany(int(r.readLen32()))
+		l = r.readLen32()
 	case b == cBin64:
-		*v = r.readMany(int(r.readLen64()))
+		l = r.readLen64()
 	default:
-		panic(fail)
+		panic(r.unexpected("bin", v))
 	}
+	return r.length(l, r.h.maxBinLen(), "MaxBinLen")
 }
 
 // DecodeString decodes a string value from the Reader.
 func (r *Reader) DecodeString(v *string) {
+	*v = r.readText(r.decodeStrLen(v))
+}
+
+func (r *Reader) decodeStrLen(v interface{}) int {
+	var l int
 	b := r.readOne()
 	switch {
 	case b >= cFixStr && b <= cFixStr+fixedStr:
-		*v = r.readText(int(b - cFixStr))
+		l = int(b - cFixStr)
 	case b == cStr8:
-		*v = r.readText(int(r.readLen8()))
+		l = r.readLen8()
 	case b == cStr16:
-		*v = r.readText(int(r.readLen16()))
+		l = r.readLen16()
 	case b == cStr32:
-		*v = r.readText(int(r.readLen32()))
+		l = r.readLen32()
 	case b == cStr64:
-		*v = r.readText(int(r.readLen64()))
+		l = r.readLen64()
 	default:
-		panic(fail)
+		panic(r.unexpected("str", v))
 	}
+	return r.length(l, r.h.maxStringLen(), "MaxStringLen")
+}
+
+// DecodeRaw decodes the encoded bytes of the next value from the Reader.
+func (r *Reader) DecodeRaw(v *Raw) {
+	*v = r.Skip()
 }
 
 // ---------------------------------------------------------------------------
@@ -177,7 +421,7 @@ func (r *Reader) DecodeInt(v *int) {
 	case b == cInt64:
 		*v = int(int64(binary.BigEndian.Uint64(r.readMany(8))))
 	default:
-		panic(fail)
+		*v = int(r.toInt(r.coerce(b, "int", v), strconv.IntSize, v))
 	}
 }
 
@@ -190,7 +434,7 @@ func (r *Reader) DecodeInt8(v *int8) {
 	case b == cInt8:
 		*v = int8(r.readOne())
 	default:
-		panic(fail)
+		*v = int8(r.toInt(r.coerce(b, "int", v), 8, v))
 	}
 }
 
@@ -205,7 +449,7 @@ func (r *Reader) DecodeInt16(v *int16) {
 	case b == cInt16:
 		*v = int16(binary.BigEndian.Uint16(r.readMany(2)))
 	default:
-		panic(fail)
+		*v = int16(r.toInt(r.coerce(b, "int", v), 16, v))
 	}
 }
 
@@ -222,7 +466,7 @@ func (r *Reader) DecodeInt32(v *int32) {
 	case b == cInt32:
 		*v = int32(binary.BigEndian.Uint32(r.readMany(4)))
 	default:
-		panic(fail)
+		*v = int32(r.toInt(r.coerce(b, "int", v), 32, v))
 	}
 }
 
@@ -241,7 +485,7 @@ func (r *Reader) DecodeInt64(v *int64) {
 	case b == cInt64:
 		*v = int64(binary.BigEndian.Uint64(r.readMany(8)))
 	default:
-		panic(fail)
+		*v = r.toInt(r.coerce(b, "int", v), 64, v)
 	}
 }
 
@@ -262,7 +506,7 @@ func (r *Reader) DecodeUint(v *uint) {
 	case b == cUint64:
 		*v = uint(binary.BigEndian.Uint64(r.readMany(8)))
 	default:
-		panic(fail)
+		*v = uint(r.toUint(r.coerce(b, "uint", v), strconv.IntSize, v))
 	}
 }
 
@@ -275,7 +519,7 @@ func (r *Reader) DecodeUint8(v *uint8) {
 	case b == cUint8:
 		*v = uint8(r.readOne())
 	default:
-		panic(fail)
+		*v = uint8(r.toUint(r.coerce(b, "uint", v), 8, v))
 	}
 }
 
@@ -290,7 +534,7 @@ func (r *Reader) DecodeUint16(v *uint16) {
 	case b == cUint16:
 		*v = uint16(binary.BigEndian.Uint16(r.readMany(2)))
 	default:
-		panic(fail)
+		*v = uint16(r.toUint(r.coerce(b, "uint", v), 16, v))
 	}
 }
 
@@ -307,7 +551,7 @@ func (r *Reader) DecodeUint32(v *uint32) {
 	case b == cUint32:
 		*v = uint32(binary.BigEndian.Uint32(r.readMany(4)))
 	default:
-		panic(fail)
+		*v = uint32(r.toUint(r.coerce(b, "uint", v), 32, v))
 	}
 }
 
@@ -326,7 +570,7 @@ func (r *Reader) DecodeUint64(v *uint64) {
 	case b == cUint64:
 		*v = uint64(binary.BigEndian.Uint64(r.readMany(8)))
 	default:
-		panic(fail)
+		*v = r.toUint(r.coerce(b, "uint", v), 64, v)
 	}
 }
 
@@ -334,17 +578,18 @@ func (r *Reader) DecodeUint64(v *uint64) {
 
 // DecodeFloat32 decodes a float32 value from the Reader.
 func (r *Reader) DecodeFloat32(v *float32) {
-	if r.readOne() == cFloat32 {
+	b := r.readOne()
+	if b == cFloat32 {
 		b := binary.BigEndian.Uint32(r.readMany(4))
 		*v = math.Float32frombits(b)
 		return
 	}
-	panic(fail)
+	*v = float32(r.toFloat(r.coerce(b, "float", v), 32, v))
 }
 
 // DecodeFloat64 decodes a float64 value from the Reader.
 func (r *Reader) DecodeFloat64(v *float64) {
-	switch r.readOne() {
+	switch b := r.readOne(); b {
 	case cFloat32:
 		b := binary.BigEndian.Uint32(r.readMany(4))
 		*v = float64(math.Float32frombits(b))
@@ -352,7 +597,7 @@ func (r *Reader) DecodeFloat64(v *float64) {
 		b := uint64(binary.BigEndian.Uint64(r.readMany(8)))
 		*v = math.Float64frombits(b)
 	default:
-		panic(fail)
+		*v = r.toFloat(r.coerce(b, "float", v), 64, v)
 	}
 }
 
@@ -366,7 +611,7 @@ func (r *Reader) DecodeComplex64(v *complex64) {
 		*v = complex(math.Float32frombits(one), math.Float32frombits(two))
 		return
 	}
-	panic(fail)
+	panic(r.unexpected("complex", v))
 }
 
 // DecodeComplex128 decodes a complex128 value from the Reader.
@@ -377,19 +622,77 @@ func (r *Reader) DecodeComplex128(v *complex128) {
 		*v = complex(math.Float64frombits(one), math.Float64frombits(two))
 		return
 	}
-	panic(fail)
+	panic(r.unexpected("complex", v))
 }
 
 // ---------------------------------------------------------------------------
 
 // DecodeTime decodes a time.Time value from the Reader.
 func (r *Reader) DecodeTime(v *time.Time) {
-	if r.readOne() == cTime {
+	switch r.readOne() {
+	case cTime:
 		b := int64(binary.BigEndian.Uint64(r.readMany(8)))
 		*v = time.Unix(0, b).UTC()
 		return
+	case cAlt:
+		if r.readOne() == cAltTime {
+			r.decodeTimeAlt(v)
+			return
+		}
+	}
+	panic(r.unexpected("time", v))
+}
+
+func (r *Reader) decodeTimeAlt(v *time.Time) {
+	var z string
+	s := int64(binary.BigEndian.Uint64(r.readMany(8)))
+	n := int64(binary.BigEndian.Uint32(r.readMany(4)))
+	o := int(int32(binary.BigEndian.Uint32(r.readMany(4))))
+	r.DecodeString(&z)
+	if o == 0 && z == "UTC" {
+		*v = time.Unix(s, n).UTC()
+		return
+	}
+	*v = time.Unix(s, n).In(time.FixedZone(z, o))
+}
+
+// DecodeDuration decodes a time.Duration value from the Reader.
+// A duration which was encoded as an integer is also accepted.
+func (r *Reader) DecodeDuration(v *time.Duration) {
+	if r.peekOne() == cAlt {
+		r.readOne()
+		if r.readOne() != cAltDur {
+			panic(r.unexpected("duration", v))
+		}
+	}
+	var x int64
+	r.DecodeInt64(&x)
+	*v = time.Duration(x)
+}
+
+// full reports whether sized integers are decoded into the
+// type of the same width when decoding into an interface.
+func (r *Reader) full() bool {
+	return r.h != nil && r.h.FullPrecisionInts
+}
+
+// peekAlt returns the sub-type byte of the next value, if
+// the next value is an alternative value, leaving both of
+// the bytes to be read again.
+func (r *Reader) peekAlt() byte {
+	if r.peekOne() != cAlt {
+		return 0
 	}
-	panic(fail)
+	r.readOne()
+	b := r.peekOne()
+	r.unread(cAlt)
+	return b
+}
+
+// peekTime reports whether the next value, whose first byte
+// is b, is a time in either of its forms.
+func (r *Reader) peekTime(b byte) bool {
+	return isTime(b) || b == cAlt && r.peekAlt() == cAltTime
 }
 
 // ---------------------------------------------------------------------------
@@ -434,22 +737,48 @@ func (r *Reader) DecodeArr(v interface{}) {
 	case *[]interface{}:
 		r.decodeArrAny(a)
 	case reflect.Value:
-		r.decodeArr(a)
+		r.decodeArr(a, c.Plan(a.Type().Elem()))
 	default:
 		r.DecodeAny(v)
 	}
 }
 
 func (r *Reader) decodeArrLen() int {
+	var l int
 	b := r.readOne()
 	switch {
 	case b >= cFixArr && b <= cFixArr+fixedArr:
-		return int(b - cFixArr)
+		l = int(b - cFixArr)
 	case b == cArr:
-		return r.readLen()
+		l = r.readCnt()
+	default:
+		panic(r.unexpected("arr", nil))
+	}
+	r.enter()
+	if l < 0 {
+		return l
+	}
+	return r.length(l, r.h.maxArrayLen(), "MaxArrayLen")
+}
+
+func (r *Reader) decodeExtLen() int {
+	var l int
+	b := r.readOne()
+	switch {
+	case b >= cFixExt && b <= cFixExt+fixedExt:
+		l = int(b - cFixExt)
+	case b == cExt8:
+		l = r.readLen8()
+	case b == cExt16:
+		l = r.readLen16()
+	case b == cExt32:
+		l = r.readLen32()
+	case b == cExt64:
+		l = r.readLen64()
 	default:
-		panic(fail)
+		panic(r.unexpected("ext", nil))
 	}
+	return r.length(l, r.h.maxBinLen(), "MaxBinLen")
 }
 
 // DecodeMap decodes a map from the Reader.
@@ -474,20 +803,26 @@ func (r *Reader) DecodeMap(v interface{}) {
 	case *map[interface{}]interface{}:
 		r.decodeMapAnyAny(m)
 	case reflect.Value:
-		r.decodeMap(m)
+		r.decodeMap(m, c.Plan(reflect.PtrTo(m.Type().Key())), c.Plan(reflect.PtrTo(m.Type().Elem())))
 	default:
 		r.DecodeAny(v)
 	}
 }
 
 func (r *Reader) decodeMapLen() int {
+	var l int
 	b := r.readOne()
 	switch {
 	case b >= cFixMap && b <= cFixMap+fixedMap:
-		return int(b - cFixMap)
+		l = int(b - cFixMap)
 	case b == cMap:
-		return r.readLen()
+		l = r.readCnt()
 	default:
-		panic(fail)
+		panic(r.unexpected("map", nil))
+	}
+	r.enter()
+	if l < 0 {
+		return l
 	}
+	return r.length(l, r.h.maxMapLen(), "MaxMapLen")
 }
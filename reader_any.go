@@ -16,6 +16,7 @@ package cork
 
 import (
 	"encoding"
+	"math/big"
 	"reflect"
 	"time"
 )
@@ -34,10 +35,12 @@ func (r *Reader) DecodeAny(v interface{}) {
 
 	case *bool:
 		r.DecodeBool(v)
-	case *byte:
-		r.DecodeByte(v)
+	case *uint8:
+		r.DecodeUint8(v)
 	case *[]byte:
 		r.DecodeBytes(v)
+	case *Raw:
+		r.DecodeRaw(v)
 	case *string:
 		r.DecodeString(v)
 	case *int:
@@ -68,6 +71,14 @@ func (r *Reader) DecodeAny(v interface{}) {
 		r.DecodeComplex128(v)
 	case *time.Time:
 		r.DecodeTime(v)
+	case *time.Duration:
+		r.DecodeDuration(v)
+	case *big.Int:
+		r.DecodeBigInt(v)
+	case *big.Float:
+		r.DecodeBigFloat(v)
+	case *big.Rat:
+		r.DecodeBigRat(v)
 
 	// -------------------------
 
@@ -19,23 +19,72 @@ import (
 	"time"
 )
 
-func (r *Reader) decodeArr(a reflect.Value) {
+func (r *Reader) decodeArr(a reflect.Value, e *plan) {
 	t := a.Type()
 	s := r.decodeArrLen()
 	if a.IsNil() || a.Len() < s {
-		a.Set(reflect.MakeSlice(t, s, s))
+		a.Set(reflect.MakeSlice(t, r.alloc(s), r.alloc(s)))
 	}
-	for i := 0; i < s; i++ {
-		r.DecodeReflect(a.Index(i))
+	p := step{elem: t.Elem()}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == a.Len() {
+			a.Set(reflect.Append(a, reflect.Zero(t.Elem())))
+		}
+		e.dec(r, a.Index(i))
 	}
 }
 
+// decodeArrFix decodes into a fixed-size array, which
+// can be from binary data for arrays of bytes. The number
+// of items must match the length of the array exactly.
+func (r *Reader) decodeArrFix(a reflect.Value, e *plan) {
+	t, o := a.Type(), r.n
+	if t.Elem().Kind() == reflect.Uint8 && isBin(r.peekOne()) {
+		s := r.decodeBinLen(a)
+		if s != a.Len() {
+			panic(&DecodeError{Offset: o, Type: t, Err: mismatch})
+		}
+		for i, v := range r.readMany(s) {
+			a.Index(i).SetUint(uint64(v))
+		}
+		return
+	}
+	s := r.decodeArrLen()
+	if s >= 0 && s != a.Len() {
+		panic(&DecodeError{Offset: o, Type: t, Err: mismatch})
+	}
+	if r.decodeArrFixItems(a, s, e) != a.Len() {
+		panic(&DecodeError{Offset: o, Type: t, Err: mismatch})
+	}
+}
+
+func (r *Reader) decodeArrFixItems(a reflect.Value, s int, e *plan) (i int) {
+	p := step{elem: a.Type().Elem()}
+	defer r.trace(&p)
+	for ; r.more(i, s); i++ {
+		p.indx = i
+		if i == a.Len() {
+			panic(&DecodeError{Offset: r.n, Type: a.Type(), Err: mismatch})
+		}
+		e.dec(r, a.Index(i))
+	}
+	return
+}
+
 func (r *Reader) decodeArrBool(a *[]bool) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]bool, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]bool, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, false)
+		}
 		r.DecodeBool(&(*a)[i])
 	}
 }
@@ -43,9 +92,15 @@ func (r *Reader) decodeArrBool(a *[]bool) {
 func (r *Reader) decodeArrInt(a *[]int) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]int, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]int, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeInt(&(*a)[i])
 	}
 }
@@ -53,9 +108,15 @@ func (r *Reader) decodeArrInt(a *[]int) {
 func (r *Reader) decodeArrInt8(a *[]int8) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]int8, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]int8, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeInt8(&(*a)[i])
 	}
 }
@@ -63,9 +124,15 @@ func (r *Reader) decodeArrInt8(a *[]int8) {
 func (r *Reader) decodeArrInt16(a *[]int16) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]int16, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]int16, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeInt16(&(*a)[i])
 	}
 }
@@ -73,9 +140,15 @@ func (r *Reader) decodeArrInt16(a *[]int16) {
 func (r *Reader) decodeArrInt32(a *[]int32) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]int32, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]int32, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeInt32(&(*a)[i])
 	}
 }
@@ -83,9 +156,15 @@ func (r *Reader) decodeArrInt32(a *[]int32) {
 func (r *Reader) decodeArrInt64(a *[]int64) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]int64, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]int64, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeInt64(&(*a)[i])
 	}
 }
@@ -93,9 +172,15 @@ func (r *Reader) decodeArrInt64(a *[]int64) {
 func (r *Reader) decodeArrUint(a *[]uint) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]uint, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]uint, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeUint(&(*a)[i])
 	}
 }
@@ -103,9 +188,15 @@ func (r *Reader) decodeArrUint(a *[]uint) {
 func (r *Reader) decodeArrUint8(a *[]uint8) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]uint8, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]uint8, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeUint8(&(*a)[i])
 	}
 }
@@ -113,9 +204,15 @@ func (r *Reader) decodeArrUint8(a *[]uint8) {
 func (r *Reader) decodeArrUint16(a *[]uint16) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]uint16, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]uint16, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeUint16(&(*a)[i])
 	}
 }
@@ -123,9 +220,15 @@ func (r *Reader) decodeArrUint16(a *[]uint16) {
 func (r *Reader) decodeArrUint32(a *[]uint32) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]uint32, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]uint32, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeUint32(&(*a)[i])
 	}
 }
@@ -133,9 +236,15 @@ func (r *Reader) decodeArrUint32(a *[]uint32) {
 func (r *Reader) decodeArrUint64(a *[]uint64) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]uint64, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]uint64, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeUint64(&(*a)[i])
 	}
 }
@@ -143,9 +252,15 @@ func (r *Reader) decodeArrUint64(a *[]uint64) {
 func (r *Reader) decodeArrString(a *[]string) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]string, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]string, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, "")
+		}
 		r.DecodeString(&(*a)[i])
 	}
 }
@@ -153,9 +268,15 @@ func (r *Reader) decodeArrString(a *[]string) {
 func (r *Reader) decodeArrFloat32(a *[]float32) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]float32, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]float32, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeFloat32(&(*a)[i])
 	}
 }
@@ -163,9 +284,15 @@ func (r *Reader) decodeArrFloat32(a *[]float32) {
 func (r *Reader) decodeArrFloat64(a *[]float64) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]float64, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]float64, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeFloat64(&(*a)[i])
 	}
 }
@@ -173,9 +300,15 @@ func (r *Reader) decodeArrFloat64(a *[]float64) {
 func (r *Reader) decodeArrComplex64(a *[]complex64) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]complex64, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]complex64, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeComplex64(&(*a)[i])
 	}
 }
@@ -183,9 +316,15 @@ func (r *Reader) decodeArrComplex64(a *[]complex64) {
 func (r *Reader) decodeArrComplex128(a *[]complex128) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]complex128, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]complex128, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, 0)
+		}
 		r.DecodeComplex128(&(*a)[i])
 	}
 }
@@ -193,9 +332,15 @@ func (r *Reader) decodeArrComplex128(a *[]complex128) {
 func (r *Reader) decodeArrTime(a *[]time.Time) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]time.Time, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]time.Time, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, time.Time{})
+		}
 		r.DecodeTime(&(*a)[i])
 	}
 }
@@ -203,9 +348,15 @@ func (r *Reader) decodeArrTime(a *[]time.Time) {
 func (r *Reader) decodeArrAny(a *[]interface{}) {
 	s := r.decodeArrLen()
 	if *a == nil || len(*a) < s {
-		*a = make([]interface{}, s)
-	}
-	for i := 0; i < s; i++ {
+		*a = make([]interface{}, r.alloc(s))
+	}
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.indx = i
+		if i == len(*a) {
+			*a = append(*a, nil)
+		}
 		r.DecodeAny(&(*a)[i])
 	}
 }
@@ -0,0 +1,186 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+)
+
+// DecodeBigInt decodes a big.Int value from the Reader. An
+// integer, or a big.Int which was encoded as text, is also
+// accepted.
+func (r *Reader) DecodeBigInt(v *big.Int) {
+	switch b := r.peekOne(); {
+	case isInt(b):
+		var x int64
+		r.DecodeInt64(&x)
+		v.SetInt64(x)
+	case isUint(b):
+		var x uint64
+		r.DecodeUint64(&x)
+		v.SetUint64(x)
+	case isBin(b):
+		if err := v.UnmarshalText(r.decodeBig(v)); err != nil {
+			panic(&DecodeError{Offset: r.n, Type: typeOf(v), Err: err})
+		}
+	default:
+		b := r.decodeBigAlt(cAltInt, "bigint", v)
+		if len(b) < 1 || b[0] > 1 {
+			panic(&DecodeError{Offset: r.n, Type: typeOf(v), Err: malformed})
+		}
+		if v.SetBytes(b[1:]); b[0] == 1 {
+			v.Neg(v)
+		}
+	}
+}
+
+// DecodeBigFloat decodes a big.Float value from the Reader.
+// A big.Float which was encoded as text is also accepted.
+func (r *Reader) DecodeBigFloat(v *big.Float) {
+	switch b := r.peekOne(); {
+	case isBin(b):
+		if err := v.UnmarshalText(r.decodeBig(v)); err != nil {
+			panic(&DecodeError{Offset: r.n, Type: typeOf(v), Err: err})
+		}
+	default:
+		if !setBigFloat(v, r.decodeBigAlt(cAltFlt, "bigfloat", v)) {
+			panic(&DecodeError{Offset: r.n, Type: typeOf(v), Err: malformed})
+		}
+	}
+}
+
+// DecodeBigRat decodes a big.Rat value from the Reader. A
+// big.Rat which was encoded as text is also accepted.
+func (r *Reader) DecodeBigRat(v *big.Rat) {
+	switch b := r.peekOne(); {
+	case isBin(b):
+		if err := v.UnmarshalText(r.decodeBig(v)); err != nil {
+			panic(&DecodeError{Offset: r.n, Type: typeOf(v), Err: err})
+		}
+	default:
+		if !setBigRat(v, r.decodeBigAlt(cAltRat, "bigrat", v)) {
+			panic(&DecodeError{Offset: r.n, Type: typeOf(v), Err: malformed})
+		}
+	}
+}
+
+// decodeBigAlt reads the header of an arbitrary-precision
+// number, which must be of the sub-type e, and returns the
+// binary data which holds the number.
+func (r *Reader) decodeBigAlt(e byte, want string, v interface{}) []byte {
+	if r.readOne() != cAlt || r.readOne() != e {
+		panic(r.unexpected(want, v))
+	}
+	return r.decodeBig(v)
+}
+
+func (r *Reader) decodeBig(v interface{}) []byte {
+	return r.readMany(r.decodeBinLen(v))
+}
+
+// setBigFloat sets v to the big.Float held in b, with
+// its precision and rounding mode, reporting whether b
+// holds a valid big.Float.
+func setBigFloat(v *big.Float, b []byte) bool {
+
+	if len(b) < 7 || b[0] > byte(big.ToPositiveInf) || b[5] > 1 {
+		return false
+	}
+
+	p := uint(binary.BigEndian.Uint32(b[1:]))
+	x := new(big.Float).SetPrec(p)
+
+	switch b[6] {
+	case bigZero:
+		if len(b) != 7 {
+			return false
+		}
+	case bigInf:
+		if len(b) != 7 {
+			return false
+		}
+		x.SetInf(false)
+	case bigFinite:
+		if len(b) < 16 {
+			return false
+		}
+		e := int64(binary.BigEndian.Uint64(b[7:]))
+		i := new(big.Int).SetBytes(b[15:])
+		n := int64(i.BitLen())
+		if n == 0 || uint(n) > p || e+n < big.MinExp || e+n > big.MaxExp {
+			return false
+		}
+		x.SetMantExp(x.SetInt(i), int(e))
+	default:
+		return false
+	}
+
+	if b[5] == 1 {
+		x.Neg(x)
+	}
+
+	v.SetPrec(p).SetMode(big.RoundingMode(b[0])).Set(x)
+
+	return true
+
+}
+
+// setBigRat sets v to the big.Rat held in b, reporting
+// whether b holds a valid big.Rat.
+func setBigRat(v *big.Rat, b []byte) bool {
+	if len(b) < 5 || b[0] > 1 {
+		return false
+	}
+	n := uint64(binary.BigEndian.Uint32(b[1:]))
+	if n > uint64(len(b)-5) || n > math.MaxInt32 {
+		return false
+	}
+	num := new(big.Int).SetBytes(b[5 : 5+n])
+	den := new(big.Int).SetBytes(b[5+n:])
+	switch {
+	case den.Sign() == 0:
+		return false
+	case num.Sign() == 0:
+		*v = big.Rat{}
+	default:
+		v.SetFrac(num, den)
+	}
+	if b[0] == 1 {
+		v.Neg(v)
+	}
+	return true
+}
+
+// createBig returns a new arbitrary-precision number of
+// the sub-type e, decoded from the Reader.
+func (r *Reader) createBig(e byte) interface{} {
+	switch e {
+	case cAltInt:
+		x := new(big.Int)
+		r.DecodeBigInt(x)
+		return x
+	case cAltFlt:
+		x := new(big.Float)
+		r.DecodeBigFloat(x)
+		return x
+	case cAltRat:
+		x := new(big.Rat)
+		r.DecodeBigRat(x)
+		return x
+	}
+	return nil
+}
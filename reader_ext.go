@@ -14,39 +14,92 @@
 
 package cork
 
+import (
+	"fmt"
+
+	"github.com/surrealdb/bump"
+)
+
 // DecodeSelfer decodes a cork.Selfer value from the Reader.
+// If the Selfer was generated by corkgen, and the next value is
+// an array or a map instead, such as a struct which was encoded
+// before it had Selfer methods, then the value is decoded directly
+// by the Selfer, without a self-describing header. If the value was
+// encoded with its length, then the Selfer must read exactly the
+// length of the value, or decoding fails.
 func (r *Reader) DecodeSelfer(v Selfer) {
-	if r.readOne() == cSlf {
-		if r.readOne() == v.ExtendCORK() {
-			v.UnmarshalCORK(r)
+	if _, ok := v.(Generated); ok {
+		if b := r.peekOne(); isArr(b) || isMap(b) {
+			r.unmarshal(v)
 			return
 		}
 	}
-	panic(fail)
+	if isExt(r.peekOne()) {
+		s := r.decodeExtLen()
+		if r.readOne() != v.ExtendCORK() {
+			panic(r.unexpected(fmt.Sprintf("ext 0x%02X", v.ExtendCORK()), v))
+		}
+		r.framed(s, v)
+		return
+	}
+	if r.readOne() != cSlf {
+		panic(r.unexpected("slf", v))
+	}
+	if r.readOne() != v.ExtendCORK() {
+		panic(r.unexpected(fmt.Sprintf("slf 0x%02X", v.ExtendCORK()), v))
+	}
+	r.unmarshal(v)
+}
+
+// unmarshal lets the Selfer decode its body from the Reader.
+// If the Selfer returns an error, then decoding is aborted,
+// with the error wrapped in a DecodeError which describes
+// the extension type and the type being decoded into,
+// unless the error is already a DecodeError from a call
+// to Decode within the Selfer.
+func (r *Reader) unmarshal(v Selfer) {
+	r.enter()
+	if err := v.UnmarshalCORK(r); err != nil {
+		if e, ok := err.(*DecodeError); ok {
+			panic(e)
+		}
+		panic(&DecodeError{Offset: r.n, Type: typeOf(v), Err: fmt.Errorf("slf 0x%02X: %w", v.ExtendCORK(), err)})
+	}
+	r.leave()
 }
 
 // DecodeCorker decodes a cork.Corker value from the Reader.
 func (r *Reader) DecodeCorker(v Corker) {
-	b := r.readOne()
-	var s int
-	switch {
-	case b >= cFixExt && b <= cFixExt+fixedExt:
-		s = int(b - cFixExt)
-	case b == cExt8:
-		s = int(r.readLen8())
-	case b == cExt16:
-		s = int(r.readLen16())
-	case b == cExt32:
-		s = int(r.readLen32())
-	case b == cExt64:
-		s = int(r.readLen64())
-	default:
-		panic(fail)
+	s := r.decodeExtLen()
+	e := r.readOne()
+	if u, ok := v.(*UnknownExt); ok {
+		u.Type = e
 	}
-	if r.readOne() != v.ExtendCORK() {
-		panic(fail)
+	if e != v.ExtendCORK() {
+		panic(r.unexpected(fmt.Sprintf("ext 0x%02X", v.ExtendCORK()), v))
 	}
 	if err := v.UnmarshalCORK(r.readMany(s)); err != nil {
-		panic(err)
+		panic(&DecodeError{Offset: r.n - s, Type: typeOf(v), Err: err})
+	}
+}
+
+// framed decodes the body of a Selfer which was encoded with
+// its length s, failing if the Selfer does not read all of it.
+func (r *Reader) framed(s int, v Selfer) {
+	r.frame(s, v, func(t *Reader) {
+		t.unmarshal(v)
+	})
+}
+
+// frame reads a value of length s using fn. The value is read
+// through a separate Reader so that fn can not read past the
+// end of the value, with the offsets continuing from those of
+// this Reader, and v being the value which is decoded into.
+func (r *Reader) frame(s int, v interface{}, fn func(t *Reader)) {
+	d := r.readMany(s)
+	t := &Reader{h: r.h, r: bump.NewReaderBytes(d), z: r.n, n: r.n - s, d: r.d}
+	fn(t)
+	if t.n != r.n {
+		panic(&DecodeError{Offset: t.n, Type: typeOf(v), Err: unframed})
 	}
 }
@@ -0,0 +1,115 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+// Peek returns the kind of the next value in the stream,
+// without reading it from the Reader, or TokenInvalid if
+// the next byte does not begin a valid value.
+func (r *Reader) Peek() TokenKind {
+	b := r.peekOne()
+	switch {
+	case b == cNil:
+		return TokenNil
+	case isBool(b):
+		return TokenBool
+	case r.peekTime(b):
+		return TokenTime
+	case isBin(b):
+		return TokenBin
+	case isStr(b):
+		return TokenStr
+	case isInt(b):
+		return TokenInt
+	case isUint(b):
+		return TokenUint
+	case b == cFloat32, b == cFloat64:
+		return TokenFloat
+	case b == cComplex64, b == cComplex128:
+		return TokenComplex
+	case isExt(b):
+		return TokenExt
+	case isArr(b):
+		return TokenArrBegin
+	case isMap(b):
+		return TokenMapBegin
+	case isSlf(b):
+		return TokenSlfBegin
+	}
+	switch r.peekAlt() {
+	case cAltDur, cAltInt:
+		return TokenInt
+	case cAltFlt, cAltRat:
+		return TokenFloat
+	}
+	return TokenInvalid
+}
+
+/*
+DecodeArrLen decodes the header of an array from the Reader,
+and returns the number of elements in it, or -1 if the array
+is an indefinite-length array. The elements must then be read
+in a loop which uses More to check whether there are any more
+elements to read.
+
+Example:
+
+	n := r.DecodeArrLen()
+	for i := 0; r.More(i, n); i++ {
+		r.DecodeString(&x[i])
+	}
+
+*/
+func (r *Reader) DecodeArrLen() int {
+	return r.decodeArrLen()
+}
+
+// DecodeMapLen decodes the header of a map from the Reader, and
+// returns the number of key-value pairs in it, or -1 if the map
+// is an indefinite-length map. The keys and values must then be
+// read in a loop which uses More, in the same way as DecodeArrLen.
+func (r *Reader) DecodeMapLen() int {
+	return r.decodeMapLen()
+}
+
+// More checks whether there are any more elements to read in
+// the array or map which was started by DecodeArrLen or by
+// DecodeMapLen, where i is the number of elements which have
+// been read so far, and n is the length which was returned.
+func (r *Reader) More(i, n int) bool {
+	return r.more(i, n)
+}
+
+// DecodeKey decodes the key of a struct field from the Reader.
+// Fields are keyed either by name, in which case the key which
+// is returned is -1, or by an integer key from a struct tag, in
+// which case the name which is returned is empty.
+func (r *Reader) DecodeKey() (name string, key int) {
+	if b := r.peekOne(); isInt(b) || isUint(b) {
+		r.DecodeInt(&key)
+		return
+	}
+	r.DecodeString(&name)
+	return name, -1
+}
+
+// DecodeNil reads a nil value from the Reader if the next
+// value is nil, and reports whether it did so.
+func (r *Reader) DecodeNil() bool {
+	if r.peekOne() == cNil {
+		r.readOne()
+		return true
+	}
+	return false
+}
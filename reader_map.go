@@ -19,17 +19,20 @@ import (
 	"time"
 )
 
-func (r *Reader) decodeMap(m reflect.Value) {
+func (r *Reader) decodeMap(m reflect.Value, x, e *plan) {
 	t := m.Type()
 	s := r.decodeMapLen()
 	if m.IsNil() {
 		m.Set(reflect.MakeMap(t))
 	}
-	for i := 0; i < s; i++ {
+	p := step{kind: stepKey, elem: t.Elem()}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		k := reflect.New(t.Key())
-		r.DecodeReflect(k)
+		x.dec(r, k)
+		p.vkey = k.Elem()
 		v := reflect.New(t.Elem())
-		r.DecodeReflect(v)
+		e.dec(r, v)
 		m.SetMapIndex(k.Elem(), v.Elem())
 	}
 }
@@ -37,12 +40,15 @@ func (r *Reader) decodeMap(m reflect.Value) {
 func (r *Reader) decodeMapStringInt(m *map[string]int) {
 	s := r.decodeMapLen()
 	if *m == nil {
-		*m = make(map[string]int, s)
+		*m = make(map[string]int, r.alloc(s))
 	}
-	for i := 0; i < s; i++ {
+	p := step{kind: stepKey}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		var k string
 		var v int
 		r.DecodeString(&k)
+		p.name = k
 		r.DecodeInt(&v)
 		(*m)[k] = v
 	}
@@ -51,12 +57,15 @@ func (r *Reader) decodeMapStringInt(m *map[string]int) {
 func (r *Reader) decodeMapStringUint(m *map[string]uint) {
 	s := r.decodeMapLen()
 	if *m == nil {
-		*m = make(map[string]uint, s)
+		*m = make(map[string]uint, r.alloc(s))
 	}
-	for i := 0; i < s; i++ {
+	p := step{kind: stepKey}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		var k string
 		var v uint
 		r.DecodeString(&k)
+		p.name = k
 		r.DecodeUint(&v)
 		(*m)[k] = v
 	}
@@ -65,12 +74,15 @@ func (r *Reader) decodeMapStringUint(m *map[string]uint) {
 func (r *Reader) decodeMapStringBool(m *map[string]bool) {
 	s := r.decodeMapLen()
 	if *m == nil {
-		*m = make(map[string]bool, s)
+		*m = make(map[string]bool, r.alloc(s))
 	}
-	for i := 0; i < s; i++ {
+	p := step{kind: stepKey}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		var k string
 		var v bool
 		r.DecodeString(&k)
+		p.name = k
 		r.DecodeBool(&v)
 		(*m)[k] = v
 	}
@@ -79,12 +91,15 @@ func (r *Reader) decodeMapStringBool(m *map[string]bool) {
 func (r *Reader) decodeMapStringString(m *map[string]string) {
 	s := r.decodeMapLen()
 	if *m == nil {
-		*m = make(map[string]string, s)
+		*m = make(map[string]string, r.alloc(s))
 	}
-	for i := 0; i < s; i++ {
+	p := step{kind: stepKey}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		var k string
 		var v string
 		r.DecodeString(&k)
+		p.name = k
 		r.DecodeString(&v)
 		(*m)[k] = v
 	}
@@ -93,12 +108,15 @@ func (r *Reader) decodeMapStringString(m *map[string]string) {
 func (r *Reader) decodeMapIntAny(m *map[int]interface{}) {
 	s := r.decodeMapLen()
 	if *m == nil {
-		*m = make(map[int]interface{}, s)
+		*m = make(map[int]interface{}, r.alloc(s))
 	}
-	for i := 0; i < s; i++ {
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		var k int
 		var v interface{}
 		r.DecodeInt(&k)
+		p.indx = k
 		r.DecodeAny(&v)
 		(*m)[k] = v
 	}
@@ -107,12 +125,15 @@ func (r *Reader) decodeMapIntAny(m *map[int]interface{}) {
 func (r *Reader) decodeMapUintAny(m *map[uint]interface{}) {
 	s := r.decodeMapLen()
 	if *m == nil {
-		*m = make(map[uint]interface{}, s)
+		*m = make(map[uint]interface{}, r.alloc(s))
 	}
-	for i := 0; i < s; i++ {
+	var p step
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		var k uint
 		var v interface{}
 		r.DecodeUint(&k)
+		p.indx = int(k)
 		r.DecodeAny(&v)
 		(*m)[k] = v
 	}
@@ -121,12 +142,15 @@ func (r *Reader) decodeMapUintAny(m *map[uint]interface{}) {
 func (r *Reader) decodeMapStringAny(m *map[string]interface{}) {
 	s := r.decodeMapLen()
 	if *m == nil {
-		*m = make(map[string]interface{}, s)
+		*m = make(map[string]interface{}, r.alloc(s))
 	}
-	for i := 0; i < s; i++ {
+	p := step{kind: stepKey}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		var k string
 		var v interface{}
 		r.DecodeString(&k)
+		p.name = k
 		r.DecodeAny(&v)
 		(*m)[k] = v
 	}
@@ -135,12 +159,15 @@ func (r *Reader) decodeMapStringAny(m *map[string]interface{}) {
 func (r *Reader) decodeMapTimeAny(m *map[time.Time]interface{}) {
 	s := r.decodeMapLen()
 	if *m == nil {
-		*m = make(map[time.Time]interface{}, s)
+		*m = make(map[time.Time]interface{}, r.alloc(s))
 	}
-	for i := 0; i < s; i++ {
+	p := step{kind: stepKey}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		var k time.Time
 		var v interface{}
 		r.DecodeTime(&k)
+		p.vkey = reflect.ValueOf(k)
 		r.DecodeAny(&v)
 		(*m)[k] = v
 	}
@@ -149,12 +176,15 @@ func (r *Reader) decodeMapTimeAny(m *map[time.Time]interface{}) {
 func (r *Reader) decodeMapAnyAny(m *map[interface{}]interface{}) {
 	s := r.decodeMapLen()
 	if *m == nil {
-		*m = make(map[interface{}]interface{}, s)
+		*m = make(map[interface{}]interface{}, r.alloc(s))
 	}
-	for i := 0; i < s; i++ {
+	p := step{kind: stepKey}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
 		var k interface{}
 		var v interface{}
 		r.DecodeAny(&k)
+		p.vkey = reflect.ValueOf(k)
 		r.DecodeAny(&v)
 		(*m)[k] = v
 	}
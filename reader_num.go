@@ -0,0 +1,154 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	numInt byte = iota
+	numUint
+	numFloat
+)
+
+// num holds a number which has been read from the stream
+// so that it can be converted into a variable of another
+// numeric type, along with the offset at which it began.
+type num struct {
+	kind byte
+	at   int
+	i    int64
+	u    uint64
+	f    float64
+}
+
+// coerce reads a number whose type byte b has already been
+// read, and which could not be decoded directly into v. The
+// decode fails unless the Handle allows numbers to be converted.
+func (r *Reader) coerce(b byte, want string, v interface{}) (n num) {
+	if r.h.coercion() == CoerceStrict {
+		panic(r.unexpected(want, v))
+	}
+	n.at = r.n - 1
+	switch {
+	case isNum(b):
+		n.kind, n.i = numInt, int64(b)
+	case b == cInt8:
+		n.kind, n.i = numInt, int64(int8(r.readOne()))
+	case b == cInt16:
+		n.kind, n.i = numInt, int64(int16(binary.BigEndian.Uint16(r.readMany(2))))
+	case b == cInt32:
+		n.kind, n.i = numInt, int64(int32(binary.BigEndian.Uint32(r.readMany(4))))
+	case b == cInt64:
+		n.kind, n.i = numInt, int64(binary.BigEndian.Uint64(r.readMany(8)))
+	case b == cUint8:
+		n.kind, n.u = numUint, uint64(r.readOne())
+	case b == cUint16:
+		n.kind, n.u = numUint, uint64(binary.BigEndian.Uint16(r.readMany(2)))
+	case b == cUint32:
+		n.kind, n.u = numUint, uint64(binary.BigEndian.Uint32(r.readMany(4)))
+	case b == cUint64:
+		n.kind, n.u = numUint, binary.BigEndian.Uint64(r.readMany(8))
+	case b == cFloat32:
+		n.kind, n.f = numFloat, float64(math.Float32frombits(binary.BigEndian.Uint32(r.readMany(4))))
+	case b == cFloat64:
+		n.kind, n.f = numFloat, math.Float64frombits(binary.BigEndian.Uint64(r.readMany(8)))
+	default:
+		panic(r.unexpected(want, v))
+	}
+	return
+}
+
+// toInt converts a number into a signed integer which
+// fits within the given number of bits.
+func (r *Reader) toInt(n num, size uint, v interface{}) int64 {
+	switch n.kind {
+	case numUint:
+		if n.u > math.MaxInt64 {
+			panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: outranged})
+		}
+		n.i = int64(n.u)
+	case numFloat:
+		t := math.Trunc(n.f)
+		if t != n.f && r.h.coercion() != CoerceLenient {
+			panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: inexact})
+		}
+		if !(t >= -(1<<63) && t < 1<<63) {
+			panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: outranged})
+		}
+		n.i = int64(t)
+	}
+	if size < 64 && (n.i < -1<<(size-1) || n.i >= 1<<(size-1)) {
+		panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: outranged})
+	}
+	return n.i
+}
+
+// toUint converts a number into an unsigned integer
+// which fits within the given number of bits.
+func (r *Reader) toUint(n num, size uint, v interface{}) uint64 {
+	switch n.kind {
+	case numInt:
+		if n.i < 0 {
+			panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: outranged})
+		}
+		n.u = uint64(n.i)
+	case numFloat:
+		t := math.Trunc(n.f)
+		if t != n.f && r.h.coercion() != CoerceLenient {
+			panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: inexact})
+		}
+		if !(t >= 0 && t < 1<<64) {
+			panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: outranged})
+		}
+		n.u = uint64(t)
+	}
+	if size < 64 && n.u >= 1<<size {
+		panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: outranged})
+	}
+	return n.u
+}
+
+// toFloat converts a number into a float with the given
+// number of bits, which must be either 32 or 64.
+func (r *Reader) toFloat(n num, size uint, v interface{}) float64 {
+	f, exact := n.f, true
+	switch n.kind {
+	case numInt:
+		f = float64(n.i)
+	case numUint:
+		f = float64(n.u)
+	}
+	if size == 32 {
+		g := float64(float32(f))
+		if math.IsInf(g, 0) && !math.IsInf(f, 0) {
+			panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: outranged})
+		}
+		exact = g == f || math.IsNaN(f)
+		f = g
+	}
+	switch n.kind {
+	case numInt:
+		exact = f < 1<<63 && int64(f) == n.i
+	case numUint:
+		exact = f < 1<<64 && uint64(f) == n.u
+	}
+	if !exact && r.h.coercion() != CoerceLenient {
+		panic(&DecodeError{Offset: n.at, Type: typeOf(v), Err: inexact})
+	}
+	return f
+}
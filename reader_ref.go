@@ -15,52 +15,95 @@
 package cork
 
 import (
+	"math/big"
 	"reflect"
+	"strconv"
 	"time"
 )
 
 // DecodeReflect decodes a reflect.Value value from the Reader.
 func (r *Reader) DecodeReflect(v reflect.Value) {
+	c.Plan(v.Type()).dec(r, v)
+}
 
-	b := r.peekOne()
+// decoder compiles the function which decodes values of
+// the type, checking the kind of the type, and whether
+// it implements the Selfer or Corker interfaces, once.
+func (c *cache) decoder(t reflect.Type) func(r *Reader, v reflect.Value) {
 
-	if b == cNil {
-		r.readOne()
-		return
+	// Raw values capture the encoded bytes
+	// of the next value in the stream, even
+	// if it is nil, so we need to check for
+	// these before anything else.
+
+	if t.Kind() == reflect.Ptr && t.Elem() == typeRaw {
+		return func(r *Reader, v reflect.Value) {
+			if v.IsNil() {
+				v.Set(reflect.New(typeRaw))
+			}
+			r.DecodeRaw(v.Interface().(*Raw))
+		}
+	}
+
+	if t == typeRaw {
+		return func(r *Reader, v reflect.Value) {
+			var x Raw
+			r.DecodeRaw(&x)
+			v.SetBytes(x)
+		}
 	}
 
-	t := v.Type()
-	k := v.Kind()
+	fn := c.decodeValue(t)
 
-	// First let's check to see if this is
-	// a nil pointer, and if it is then we
-	// will create a new value for the
-	// underlying type, and set the pointer
-	// to this value.
+	// Otherwise a nil value in the stream
+	// leaves the value as it is, whatever
+	// the type of the value may be.
 
-	if v.Kind() == reflect.Ptr && v.IsNil() {
-		n := reflect.New(t.Elem())
-		v.Set(n)
+	return func(r *Reader, v reflect.Value) {
+		if r.peekOne() == cNil {
+			r.readOne()
+			return
+		}
+		fn(r, v)
 	}
 
+}
+
+func (c *cache) decodeValue(t reflect.Type) func(r *Reader, v reflect.Value) {
+
+	k := t.Kind()
+
 	// Next let's check to see if the type
 	// implements either the Selfer or Corker
 	// interfaces, and if it does then decode
-	// it directly. Caching the interface
-	// detection speeds up the decoding.
+	// it directly. If the pointer is nil we
+	// create a new value for the underlying
+	// type, but if the pointer can't be set,
+	// such as the address of a struct field,
+	// then we decode into the value in place.
 
 	if c.Selfable(t) {
-		n := reflect.New(t.Elem())
-		r.DecodeSelfer(n.Interface().(Selfer))
-		v.Set(n)
-		return
+		return func(r *Reader, v reflect.Value) {
+			if !v.CanSet() {
+				r.DecodeSelfer(v.Interface().(Selfer))
+				return
+			}
+			n := reflect.New(t.Elem())
+			r.DecodeSelfer(n.Interface().(Selfer))
+			v.Set(n)
+		}
 	}
 
 	if c.Corkable(t) {
-		n := reflect.New(t.Elem())
-		r.DecodeCorker(n.Interface().(Corker))
-		v.Set(n)
-		return
+		return func(r *Reader, v reflect.Value) {
+			if !v.CanSet() {
+				r.DecodeCorker(v.Interface().(Corker))
+				return
+			}
+			n := reflect.New(t.Elem())
+			r.DecodeCorker(n.Interface().(Corker))
+			v.Set(n)
+		}
 	}
 
 	// It wasn't a self describing interface
@@ -71,167 +114,379 @@ func (r *Reader) DecodeReflect(v reflect.Value) {
 	switch t {
 
 	case typeStr:
-		var x string
-		r.DecodeString(&x)
-		v.SetString(x)
-		return
+		return func(r *Reader, v reflect.Value) {
+			var x string
+			r.DecodeString(&x)
+			v.SetString(x)
+		}
 
 	case typeBit:
-		var x []byte
-		r.DecodeBytes(&x)
-		v.SetBytes(x)
-		return
+		return func(r *Reader, v reflect.Value) {
+			var x []byte
+			r.DecodeBytes(&x)
+			v.SetBytes(x)
+		}
 
 	case typeTime:
-		var x time.Time
-		r.DecodeTime(&x)
-		v.Set(reflect.ValueOf(x))
-		return
+		return func(r *Reader, v reflect.Value) {
+			var x time.Time
+			r.DecodeTime(&x)
+			v.Set(reflect.ValueOf(x))
+		}
+
+	case typeDur:
+		return func(r *Reader, v reflect.Value) {
+			var x time.Duration
+			r.DecodeDuration(&x)
+			v.SetInt(int64(x))
+		}
+
+	case typeBigInt:
+		return func(r *Reader, v reflect.Value) {
+			var x big.Int
+			r.DecodeBigInt(&x)
+			v.Set(reflect.ValueOf(x))
+		}
+
+	case typeBigFlt:
+		return func(r *Reader, v reflect.Value) {
+			var x big.Float
+			r.DecodeBigFloat(&x)
+			v.Set(reflect.ValueOf(x))
+		}
+
+	case typeBigRat:
+		return func(r *Reader, v reflect.Value) {
+			var x big.Rat
+			r.DecodeBigRat(&x)
+			v.Set(reflect.ValueOf(x))
+		}
 
 	}
 
 	// Otherwise let's switch over all of the
 	// possible types that this item can be
-	// and decode it into the correct type.
-	// For structs, we will cache the struct
-	// fields, so that we do not have to parse
-	// these for every item that we process.
+	// and decode it into the correct type,
+	// resolving the plans for any elements,
+	// keys, and struct fields up front.
 
 	switch k {
 
 	case reflect.Ptr:
-		r.DecodeReflect(v.Elem())
+		e := c.plan(t.Elem())
+		return func(r *Reader, v reflect.Value) {
+			if v.IsNil() {
+				v.Set(reflect.New(t.Elem()))
+			}
+			e.dec(r, v.Elem())
+		}
 
 	case reflect.Map:
-		r.decodeMap(v)
+		x, e := c.plan(reflect.PtrTo(t.Key())), c.plan(reflect.PtrTo(t.Elem()))
+		return func(r *Reader, v reflect.Value) {
+			r.decodeMap(v, x, e)
+		}
 
 	case reflect.Slice:
-		r.decodeArr(v)
+		e := c.plan(t.Elem())
+		return func(r *Reader, v reflect.Value) {
+			r.decodeArr(v, e)
+		}
+
+	case reflect.Array:
+		e := c.plan(t.Elem())
+		return func(r *Reader, v reflect.Value) {
+			r.decodeArrFix(v, e)
+		}
 
 	case reflect.Bool:
-		var x bool
-		r.DecodeBool(&x)
-		v.SetBool(x)
+		return func(r *Reader, v reflect.Value) {
+			var x bool
+			r.DecodeBool(&x)
+			v.SetBool(x)
+		}
 
 	case reflect.String:
-		var x string
-		r.DecodeString(&x)
-		v.SetString(x)
+		return func(r *Reader, v reflect.Value) {
+			var x string
+			r.DecodeString(&x)
+			v.SetString(x)
+		}
 
 	case reflect.Int:
-		var x int
-		r.DecodeInt(&x)
-		v.SetInt(int64(x))
+		return func(r *Reader, v reflect.Value) {
+			var x int
+			r.DecodeInt(&x)
+			v.SetInt(int64(x))
+		}
 
 	case reflect.Int8:
-		var x int8
-		r.DecodeInt8(&x)
-		v.SetInt(int64(x))
+		return func(r *Reader, v reflect.Value) {
+			var x int8
+			r.DecodeInt8(&x)
+			v.SetInt(int64(x))
+		}
 
 	case reflect.Int16:
-		var x int16
-		r.DecodeInt16(&x)
-		v.SetInt(int64(x))
+		return func(r *Reader, v reflect.Value) {
+			var x int16
+			r.DecodeInt16(&x)
+			v.SetInt(int64(x))
+		}
 
 	case reflect.Int32:
-		var x int32
-		r.DecodeInt32(&x)
-		v.SetInt(int64(x))
+		return func(r *Reader, v reflect.Value) {
+			var x int32
+			r.DecodeInt32(&x)
+			v.SetInt(int64(x))
+		}
 
 	case reflect.Int64:
-		var x int64
-		r.DecodeInt64(&x)
-		v.SetInt(x)
+		return func(r *Reader, v reflect.Value) {
+			var x int64
+			r.DecodeInt64(&x)
+			v.SetInt(x)
+		}
 
 	case reflect.Uint:
-		var x uint
-		r.DecodeUint(&x)
-		v.SetUint(uint64(x))
+		return func(r *Reader, v reflect.Value) {
+			var x uint
+			r.DecodeUint(&x)
+			v.SetUint(uint64(x))
+		}
 
 	case reflect.Uint8:
-		var x uint8
-		r.DecodeUint8(&x)
-		v.SetUint(uint64(x))
+		return func(r *Reader, v reflect.Value) {
+			var x uint8
+			r.DecodeUint8(&x)
+			v.SetUint(uint64(x))
+		}
 
 	case reflect.Uint16:
-		var x uint16
-		r.DecodeUint16(&x)
-		v.SetUint(uint64(x))
+		return func(r *Reader, v reflect.Value) {
+			var x uint16
+			r.DecodeUint16(&x)
+			v.SetUint(uint64(x))
+		}
 
 	case reflect.Uint32:
-		var x uint32
-		r.DecodeUint32(&x)
-		v.SetUint(uint64(x))
+		return func(r *Reader, v reflect.Value) {
+			var x uint32
+			r.DecodeUint32(&x)
+			v.SetUint(uint64(x))
+		}
 
 	case reflect.Uint64:
-		var x uint64
-		r.DecodeUint64(&x)
-		v.SetUint(x)
+		return func(r *Reader, v reflect.Value) {
+			var x uint64
+			r.DecodeUint64(&x)
+			v.SetUint(x)
+		}
 
 	case reflect.Float32:
-		var x float32
-		r.DecodeFloat32(&x)
-		v.SetFloat(float64(x))
+		return func(r *Reader, v reflect.Value) {
+			var x float32
+			r.DecodeFloat32(&x)
+			v.SetFloat(float64(x))
+		}
 
 	case reflect.Float64:
-		var x float64
-		r.DecodeFloat64(&x)
-		v.SetFloat(x)
+		return func(r *Reader, v reflect.Value) {
+			var x float64
+			r.DecodeFloat64(&x)
+			v.SetFloat(x)
+		}
 
 	case reflect.Complex64:
-		var x complex64
-		r.DecodeComplex64(&x)
-		v.SetComplex(complex128(x))
+		return func(r *Reader, v reflect.Value) {
+			var x complex64
+			r.DecodeComplex64(&x)
+			v.SetComplex(complex128(x))
+		}
 
 	case reflect.Complex128:
-		var x complex128
-		r.DecodeComplex128(&x)
-		v.SetComplex(x)
+		return func(r *Reader, v reflect.Value) {
+			var x complex128
+			r.DecodeComplex128(&x)
+			v.SetComplex(x)
+		}
 
 	case reflect.Interface:
-		var x interface{}
-		r.DecodeInterface(&x)
-		if reflect.ValueOf(x).IsValid() {
-			v.Set(reflect.ValueOf(x))
+		return func(r *Reader, v reflect.Value) {
+			var x interface{}
+			r.DecodeInterface(&x)
+			if reflect.ValueOf(x).IsValid() {
+				v.Set(reflect.ValueOf(x))
+			}
 		}
 
 	case reflect.Struct:
-
-		if !c.Has(t) {
-			tot := 0
-			fls := make([]*field, t.NumField())
-			for i := 0; i < t.NumField(); i++ {
-				if f := newField(t.Field(i)); f != nil {
-					fls[tot] = f
-					tot++
-				}
+		fls, err := c.fields(t)
+		if err != nil {
+			return func(r *Reader, v reflect.Value) {
+				panic(&DecodeError{Offset: r.n, Type: t, Err: err})
 			}
-			c.Set(t, fls[:tot])
 		}
+		s := newStruct(fls)
+		return func(r *Reader, v reflect.Value) {
+			r.decodeStruct(v, s)
+		}
+
+	}
+
+	return func(r *Reader, v reflect.Value) {}
+
+}
+
+// structure holds the fields of a struct, along with
+// indexes of the fields by name and by integer key, so
+// that each key in the stream is found without having
+// to check each of the fields in turn.
+type structure struct {
+	fls  []*field
+	name map[string]*field
+	keys map[int]*field
+}
+
+func newStruct(fls []*field) *structure {
+	s := &structure{
+		fls:  fls,
+		name: make(map[string]*field, len(fls)),
+		keys: make(map[int]*field),
+	}
+	for _, f := range fls {
+		if _, ok := s.name[f.Name()]; !ok {
+			s.name[f.Name()] = f
+		}
+		if f.keyd {
+			s.keys[f.key] = f
+		}
+	}
+	return s
+}
+
+// decodeStruct decodes a struct from a map keyed by field
+// name or integer key, or from an array of field values.
+func (r *Reader) decodeStruct(v reflect.Value, x *structure) {
+
+	// A struct which was encoded using Selfer
+	// methods from corkgen has the same body
+	// as a struct encoded using reflection, so
+	// we check the header and decode the body,
+	// which must fill its length if it has one.
+
+	if isExt(r.peekOne()) {
+		s := r.decodeExtLen()
+		r.generated(r.readOne(), v)
+		r.frame(s, v, func(t *Reader) {
+			t.decodeStruct(v, x)
+		})
+		return
+	}
+
+	if r.peekOne() == cSlf {
+		r.readOne()
+		r.generated(r.readOne(), v)
+	}
+
+	if isArr(r.peekOne()) {
+		r.decodeStructArr(v, x.fls)
+		return
+	}
 
-		x := c.Get(t)
-		s := r.decodeMapLen()
+	s := r.decodeMapLen()
 
-		for i := 0; i < s; i++ {
+	p := step{kind: stepField}
+	defer r.trace(&p)
 
-			var k string
-			r.DecodeString(&k)
+	for i := 0; r.more(i, s); i++ {
 
-			for _, f := range x {
-				if k == f.Name() {
-					if f := v.FieldByIndex(f.indx); f.CanSet() {
-						if v.CanAddr() {
-							r.DecodeReflect(f.Addr())
-						} else {
-							r.DecodeReflect(f)
-						}
-					}
-					continue
-				}
+		// Fields are keyed by name, or by
+		// number if the field has a key in
+		// its tag, so we accept either. The
+		// name is only copied into a string
+		// if it does not match any field.
+
+		var f *field
+
+		if b := r.peekOne(); isInt(b) || isUint(b) {
+			var n int
+			r.DecodeInt(&n)
+			p.name, p.elem = strconv.Itoa(n), nil
+			f = x.keys[n]
+		} else {
+			k := r.readMany(r.decodeStrLen((*string)(nil)))
+			if f = x.name[string(k)]; f == nil {
+				p.name, p.elem = string(k), nil
+			}
+		}
+
+		if f != nil {
+			if fv := fieldFor(v, f.indx); fv.IsValid() && fv.CanSet() {
+				p.name, p.elem = f.Name(), fv.Type()
+				r.decodeField(fv, f)
+				continue
 			}
+		}
 
+		// If the key did not match any of
+		// the fields on the struct, then we
+		// skip its value so that the stream
+		// stays in sync for the next key.
+
+		r.skip()
+
+	}
+
+}
+
+// generated checks that the extension type e, which was found
+// in place of a struct, is registered to a type with generated
+// Selfer methods, which has the same fields as the struct.
+func (r *Reader) generated(e byte, v reflect.Value) {
+	if t, ok := r.h.registry().lookup(e); ok && t.ConvertibleTo(v.Type()) {
+		if _, ok := reflect.New(t).Interface().(Generated); ok {
+			return
 		}
+	}
+	panic(r.unexpected("map", v))
+}
 
+// decodeStructArr decodes a struct which was encoded as
+// an array, by the position of each field in the struct.
+// Any extra values, for fields which have since been
+// removed from the struct, are skipped.
+func (r *Reader) decodeStructArr(v reflect.Value, x []*field) {
+	s := r.decodeArrLen()
+	p := step{kind: stepField}
+	defer r.trace(&p)
+	for i := 0; r.more(i, s); i++ {
+		p.name, p.elem = strconv.Itoa(i), nil
+		if i < len(x) {
+			// A nil for a field behind a nil
+			// embedded pointer leaves the pointer
+			// as nil, rather than allocating it.
+			if r.peekOne() == cNil && !fieldOf(v, x[i].indx).IsValid() {
+				r.readOne()
+				continue
+			}
+			if fv := fieldFor(v, x[i].indx); fv.IsValid() && fv.CanSet() {
+				p.name, p.elem = x[i].Name(), fv.Type()
+				r.decodeField(fv, x[i])
+				continue
+			}
+		}
+		r.skip()
 	}
+}
 
+// decodeField decodes into a field of a struct, using
+// its address where possible, so that any methods which
+// have pointer receivers are used.
+func (r *Reader) decodeField(v reflect.Value, f *field) {
+	if v.CanAddr() {
+		f.ptr.dec(r, v.Addr())
+	} else {
+		f.val.dec(r, v)
+	}
 }
@@ -0,0 +1,134 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"time"
+)
+
+// Skip consumes the next complete value from the Reader without
+// decoding it, and returns the encoded bytes of the skipped value.
+func (r *Reader) Skip() []byte {
+	return r.SkipN(1)
+}
+
+// SkipN consumes the next n complete values from the Reader without
+// decoding them, and returns the encoded bytes of the skipped values.
+func (r *Reader) SkipN(n int) []byte {
+	return r.capture(func() {
+		r.skipN(n)
+	})
+}
+
+// capture records the bytes which are read from the
+// stream whilst running fn. If we are already recording
+// the stream, for instance when a Selfer skips a value
+// whilst it is itself being skipped, then we take a
+// copy of the nested span only.
+func (r *Reader) capture(fn func()) []byte {
+
+	if r.b != nil {
+		i := len(*r.b)
+		fn()
+		return append([]byte(nil), (*r.b)[i:]...)
+	}
+
+	var b []byte
+
+	r.b = &b
+	defer func() { r.b = nil }()
+	fn()
+
+	return b
+
+}
+
+func (r *Reader) skipN(n int) {
+	for i := 0; i < n; i++ {
+		r.skip()
+	}
+}
+
+func (r *Reader) skip() {
+	switch b := r.peekOne(); {
+	case isStr(b):
+		r.readMany(r.decodeStrLen(nil))
+		return
+	case isBin(b):
+		r.readMany(r.decodeBinLen(nil))
+		return
+	case isExt(b):
+		r.readMany(r.decodeExtLen() + 1)
+		return
+	case isArr(b):
+		s := r.decodeArrLen()
+		for i := 0; r.more(i, s); i++ {
+			r.skip()
+		}
+		return
+	case isMap(b):
+		s := r.decodeMapLen()
+		for i := 0; r.more(i, s); i++ {
+			r.skip()
+			r.skip()
+		}
+		return
+	case b == cAlt:
+		r.skipAlt()
+		return
+	}
+	b := r.readOne()
+	switch {
+	case b == cNil, isBool(b), isNum(b):
+		return
+	case b == cInt8, b == cUint8:
+		r.readMany(1)
+	case b == cInt16, b == cUint16:
+		r.readMany(2)
+	case b == cInt32, b == cUint32, b == cFloat32:
+		r.readMany(4)
+	case b == cInt64, b == cUint64, b == cFloat64, b == cComplex64, b == cTime:
+		r.readMany(8)
+	case b == cComplex128:
+		r.readMany(16)
+	case b == cSlf:
+		r.skipSlf()
+	default:
+		panic(r.unexpected("value", nil))
+	}
+}
+
+// skipAlt consumes an alternative value by decoding it, so
+// that a value is only skipped if it could also be decoded.
+// A break is not a value, and so can not be skipped.
+func (r *Reader) skipAlt() {
+	if r.peekAlt() == cAltTime {
+		var x time.Time
+		r.DecodeTime(&x)
+		return
+	}
+	r.createAlt()
+}
+
+// skipSlf consumes the body of a self-describing value. The
+// body of a Selfer is not length-prefixed, so the only way
+// to find its end is to let the registered type decode it.
+func (r *Reader) skipSlf() {
+	r.skipSlfBody(r.readOne())
+}
+
+func (r *Reader) skipSlfBody(e byte) {
+	r.unmarshal(r.registered(e))
+}
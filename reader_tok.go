@@ -0,0 +1,228 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/surrealdb/bump"
+)
+
+type frame struct {
+	kind TokenKind
+	size int
+	read int
+	prev *bump.Reader
+}
+
+/*
+Next reads the next token from the stream, without needing to know
+the type of the data up front. When the end of the stream is reached
+in between two top-level values, Next returns io.EOF.
+
+Arrays and maps are returned as a begin token, followed by the tokens
+of each element (or of each key and value in turn), and then an end
+token. The begin token of an indefinite-length array or map has a Len
+of -1, and its end token is returned once the break is read.
+Self-describing values are returned in the same way, with the
+tokens of the values written by the Selfer in between. The body of a
+self-describing value carries no length, so its type must have been
+registered using the Register method.
+
+Example:
+
+	dec := cork.NewDecoderBytes(src)
+	for {
+		tok, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(tok.Kind, tok.Value)
+	}
+*/
+func (r *Reader) Next() (tok Token, err error) {
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = r.wrap(v)
+		}
+	}()
+
+	// If we are inside a container, then check
+	// whether all of its values have been read,
+	// and if so, return the matching end token.
+	// The break of an indefinite-length map can
+	// only come before a key, never a value.
+
+	if n := len(r.t); n > 0 {
+		f := &r.t[n-1]
+		if f.kind != TokenMapBegin || f.read%2 == 0 {
+			if !r.more(f.read, f.size) {
+				r.t = r.t[:n-1]
+				if f.prev != nil {
+					r.r = f.prev
+				}
+				// Each end kind directly
+				// follows its begin kind.
+				return Token{Kind: f.kind + 1}, nil
+			}
+		}
+		f.read++
+	} else if _, err := r.r.PeekByte(); err != nil {
+		return tok, err
+	}
+
+	return r.token(), nil
+
+}
+
+func (r *Reader) token() Token {
+
+	b := r.peekOne()
+
+	switch {
+	case b == cNil:
+		r.readOne()
+		return Token{Kind: TokenNil}
+	case isBool(b):
+		var x bool
+		r.DecodeBool(&x)
+		return Token{Kind: TokenBool, Value: x}
+	case r.peekTime(b):
+		var x time.Time
+		r.DecodeTime(&x)
+		return Token{Kind: TokenTime, Value: x}
+	case isBin(b):
+		var x []byte
+		r.DecodeBytes(&x)
+		return Token{Kind: TokenBin, Len: len(x), Value: x}
+	case isStr(b):
+		var x string
+		r.DecodeString(&x)
+		return Token{Kind: TokenStr, Len: len(x), Value: x}
+	case isInt(b):
+		var x int
+		r.DecodeInt(&x)
+		return Token{Kind: TokenInt, Value: x}
+	case isUint(b):
+		var x uint
+		r.DecodeUint(&x)
+		return Token{Kind: TokenUint, Value: x}
+	case b == cFloat32:
+		var x float32
+		r.DecodeFloat32(&x)
+		return Token{Kind: TokenFloat, Value: x}
+	case b == cFloat64:
+		var x float64
+		r.DecodeFloat64(&x)
+		return Token{Kind: TokenFloat, Value: x}
+	case b == cComplex64:
+		var x complex64
+		r.DecodeComplex64(&x)
+		return Token{Kind: TokenComplex, Value: x}
+	case b == cComplex128:
+		var x complex128
+		r.DecodeComplex128(&x)
+		return Token{Kind: TokenComplex, Value: x}
+	case isExt(b):
+		s := r.decodeExtLen()
+		e := r.readOne()
+		x := r.readMany(s)
+		return Token{Kind: TokenExt, Len: s, Ext: e, Value: x}
+	case isArr(b):
+		s := r.decodeArrLen()
+		r.t = append(r.t, frame{kind: TokenArrBegin, size: s})
+		return Token{Kind: TokenArrBegin, Len: s}
+	case isMap(b):
+		s := r.decodeMapLen()
+		n := s * 2
+		if s < 0 {
+			n = -1
+		}
+		r.t = append(r.t, frame{kind: TokenMapBegin, size: n})
+		return Token{Kind: TokenMapBegin, Len: s}
+	case isSlf(b):
+		return r.tokenSlf()
+	case b == cAlt:
+		switch x := r.createAlt().(type) {
+		case *big.Float, *big.Rat:
+			return Token{Kind: TokenFloat, Value: x}
+		default:
+			return Token{Kind: TokenInt, Value: x}
+		}
+	}
+
+	r.readOne()
+
+	panic(r.unexpected("value", nil))
+
+}
+
+// tokenSlf reads the body of a self-describing value by
+// letting the registered type decode it, and then switches
+// the Reader over to the recorded body, so that the values
+// inside it can be returned as tokens.
+func (r *Reader) tokenSlf() Token {
+
+	r.readOne()
+
+	e := r.readOne()
+
+	b := append([]byte{}, r.capture(func() {
+		r.skipSlfBody(e)
+	})...)
+
+	s := 0
+
+	for t := (&Reader{h: r.h, r: bump.NewReaderBytes(b), z: len(b)}); ; s++ {
+		if _, err := t.r.PeekByte(); err != nil {
+			break
+		}
+		t.skip()
+	}
+
+	// The body has already been read once, so we
+	// move the offset back to the start of it, so
+	// that the offsets of the values inside it are
+	// reported correctly when they are read again.
+
+	r.enter()
+	r.n -= len(b)
+	r.t = append(r.t, frame{kind: TokenSlfBegin, size: s, prev: r.r})
+	r.r = bump.NewReaderBytes(b)
+
+	return Token{Kind: TokenSlfBegin, Len: s, Ext: e}
+
+}
+
+// reset discards any containers which are still open,
+// restoring the underlying reader if we were part way
+// through the body of a self-describing value.
+func (r *Reader) reset() {
+	for i := len(r.t) - 1; i >= 0; i-- {
+		if r.t[i].prev != nil {
+			r.r = r.t[i].prev
+		}
+	}
+	r.t = r.t[:0]
+	r.p = false
+	r.e = nil
+	r.n = 0
+	r.d = 0
+}
@@ -15,7 +15,7 @@
 package cork
 
 import (
-	"encoding/binary"
+	"fmt"
 	"reflect"
 	"time"
 )
@@ -34,7 +34,7 @@ func (r *Reader) DecodeInterface(v *interface{}) {
 		var x bool
 		r.DecodeBool(&x)
 		*v = x
-	case isTime(b):
+	case r.peekTime(b):
 		var x time.Time
 		r.DecodeTime(&x)
 		*v = x
@@ -50,6 +50,10 @@ func (r *Reader) DecodeInterface(v *interface{}) {
 		var x int
 		r.DecodeInt(&x)
 		*v = x
+	case isInt(b) && r.full():
+		*v = r.createInt(b)
+	case isUint(b) && r.full():
+		*v = r.createUint(b)
 	case isInt(b):
 		var x int
 		r.DecodeInt(&x)
@@ -85,50 +89,123 @@ func (r *Reader) DecodeInterface(v *interface{}) {
 		*v = r.createArr()
 	case isMap(b):
 		*v = r.createMap()
+	case b == cAlt:
+		*v = r.createAlt()
 
 	// -------------------------
 
 	default:
-		panic(fail)
+		r.readOne()
+		panic(r.unexpected("value", v))
 
 	}
 
 }
 
-func (r *Reader) createExt() (v Corker) {
-	s := 0
-	b := r.readOne()
-	switch {
-	case b >= cFixExt && b <= cFixExt+fixedExt:
-		s = int(b - cFixExt)
-	case b == cExt8:
-		s = int(r.readOne())
-	case b == cExt16:
-		s = int(binary.BigEndian.Uint16(r.readMany(2)))
-	case b == cExt32:
-		s = int(binary.BigEndian.Uint32(r.readMany(4)))
-	case b == cExt64:
-		s = int(binary.BigEndian.Uint64(r.readMany(8)))
+// createInt decodes a signed integer into the type which has
+// the same width as the integer in the stream.
+func (r *Reader) createInt(b byte) interface{} {
+	switch b {
+	case cInt8:
+		var x int8
+		r.DecodeInt8(&x)
+		return x
+	case cInt16:
+		var x int16
+		r.DecodeInt16(&x)
+		return x
+	case cInt32:
+		var x int32
+		r.DecodeInt32(&x)
+		return x
+	default:
+		var x int64
+		r.DecodeInt64(&x)
+		return x
+	}
+}
+
+// createUint decodes an unsigned integer into the type which
+// has the same width as the integer in the stream.
+func (r *Reader) createUint(b byte) interface{} {
+	switch b {
+	case cUint8:
+		var x uint8
+		r.DecodeUint8(&x)
+		return x
+	case cUint16:
+		var x uint16
+		r.DecodeUint16(&x)
+		return x
+	case cUint32:
+		var x uint32
+		r.DecodeUint32(&x)
+		return x
 	default:
-		panic(fail)
+		var x uint64
+		r.DecodeUint64(&x)
+		return x
 	}
+}
+
+func (r *Reader) createAlt() interface{} {
+	switch e := r.peekAlt(); e {
+	case cAltDur:
+		var x time.Duration
+		r.DecodeDuration(&x)
+		return x
+	case cAltInt, cAltFlt, cAltRat:
+		return r.createBig(e)
+	}
+	r.readOne()
+	r.readOne()
+	panic(r.unexpected("value", nil))
+}
+
+func (r *Reader) createExt() interface{} {
+	var v Corker
+	s := r.decodeExtLen()
 	e := r.readOne()
+	if t, ok := r.h.registry().lookup(e); ok {
+		switch x := reflect.New(t).Interface().(type) {
+		case Selfer:
+			r.framed(s, x)
+			return x
+		case Corker:
+			v = x
+		}
+	}
 	d := r.readMany(s)
-	v = reflect.New(registry[e]).Interface().(Corker)
+	if v == nil {
+		v = &UnknownExt{Type: e}
+	}
 	if err := v.UnmarshalCORK(d); err != nil {
 		panic(err)
 	}
-	return
+	return v
 }
 
 func (r *Reader) createSlf() (v Selfer) {
 	if r.readOne() == cSlf {
-		e := r.readOne()
-		v = reflect.New(registry[e]).Interface().(Selfer)
-		v.UnmarshalCORK(r)
+		v = r.registered(r.readOne())
+		r.unmarshal(v)
 		return
 	}
-	panic(fail)
+	panic(r.unexpected("slf", nil))
+}
+
+// registered returns a new value of the Selfer type which is
+// registered with the extension type. The body of an unframed
+// Selfer is not length-prefixed, so if the type is not
+// registered then the rest of the stream can't be read, and
+// we fail.
+func (r *Reader) registered(e byte) Selfer {
+	if t, ok := r.h.registry().lookup(e); ok {
+		if v, ok := reflect.New(t).Interface().(Selfer); ok {
+			return v
+		}
+	}
+	panic(&DecodeError{Offset: r.n - 1, Got: e, Err: fmt.Errorf("%w 0x%02X", unregistered, e)})
 }
 
 func (r *Reader) createArr() (v interface{}) {
@@ -200,7 +277,7 @@ func (r *Reader) createArr() (v interface{}) {
 			return x
 		case reflect.Type:
 			var x = reflect.MakeSlice(a, 0, 0)
-			r.decodeArr(x)
+			r.decodeArr(x, c.Plan(x.Type().Elem()))
 			return x.Interface()
 		}
 	}
@@ -247,7 +324,7 @@ func (r *Reader) createMap() (v interface{}) {
 			return x
 		case reflect.Type:
 			var x = reflect.MakeMap(m)
-			r.decodeMap(x)
+			r.decodeMap(x, c.Plan(reflect.PtrTo(x.Type().Key())), c.Plan(reflect.PtrTo(x.Type().Elem())))
 			return x.Interface()
 		}
 	}
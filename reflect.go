@@ -15,12 +15,29 @@
 package cork
 
 import (
+	"math/big"
 	"reflect"
 	"time"
 )
 
 var typeStr = reflect.TypeOf("")
 var typeBit = reflect.TypeOf([]uint8(nil))
+var typeRaw = reflect.TypeOf(Raw(nil))
 var typeTime = reflect.TypeOf(time.Now())
+var typeDur = reflect.TypeOf(time.Duration(0))
+var typeBigInt = reflect.TypeOf(big.Int{})
+var typeBigFlt = reflect.TypeOf(big.Float{})
+var typeBigRat = reflect.TypeOf(big.Rat{})
+var typeUnknown = reflect.TypeOf(UnknownExt{})
 var typeSelfer = reflect.TypeOf((*Selfer)(nil)).Elem()
 var typeCorker = reflect.TypeOf((*Corker)(nil)).Elem()
+
+// isNative reports whether the struct type is encoded as a
+// single value of its own, rather than as a map of fields.
+func isNative(t reflect.Type) bool {
+	switch t {
+	case typeTime, typeBigInt, typeBigFlt, typeBigRat:
+		return true
+	}
+	return false
+}
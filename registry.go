@@ -15,20 +15,92 @@
 package cork
 
 import (
+	"fmt"
 	"reflect"
+	"sync"
 )
 
-var registry = make(map[byte]reflect.Type)
+// Registry holds the Corker and Selfer types which can be
+// created when decoding into a nil interface, keyed by the
+// extension type byte of each type. A Registry can be set
+// on a Handle, so that separate parts of a program can use
+// the same extension type bytes for different types. It is
+// safe to use a Registry from multiple goroutines.
+type Registry struct {
+	lock  sync.RWMutex
+	types map[byte]reflect.Type
+}
+
+// DefaultRegistry is the Registry which is used by a Handle
+// which has no Registry, and to which Register adds types.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		types: make(map[byte]reflect.Type),
+	}
+}
 
-// Register adds a Corker type to the registry, enabling the
-// object type to be encoded and decoded using the Corker methods.
+// Register adds a Corker or Selfer type to the default registry,
+// enabling the object type to be encoded and decoded using the
+// Corker or Selfer methods. It panics if the type can not be
+// added to the registry.
 func Register(value interface{}) {
+	DefaultRegistry.Register(value)
+}
+
+// Register adds a Corker or Selfer type to the registry, in the
+// same way as Add, but panics if the type can not be added.
+func (r *Registry) Register(value interface{}) {
+	if err := r.Add(value); err != nil {
+		panic(err)
+	}
+}
+
+// Add adds a Corker or Selfer type to the registry. An error is
+// returned if the value is not a pointer to a Corker or Selfer,
+// or if another type has already been registered with the same
+// extension type. Adding the same type more than once has no
+// effect.
+func (r *Registry) Add(value interface{}) error {
+
+	var e byte
 
 	switch val := value.(type) {
 	case Corker:
-		registry[val.ExtendCORK()] = reflect.TypeOf(val).Elem()
+		e = val.ExtendCORK()
 	case Selfer:
-		registry[val.ExtendCORK()] = reflect.TypeOf(val).Elem()
+		e = val.ExtendCORK()
+	default:
+		return fmt.Errorf("cork: can't register %T, as it is not a Corker or Selfer", value)
 	}
 
+	t := reflect.TypeOf(value)
+
+	if t.Kind() != reflect.Ptr {
+		return fmt.Errorf("cork: can't register %s, as it is not a pointer", t)
+	}
+
+	t = t.Elem()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if o, ok := r.types[e]; ok && o != t {
+		return fmt.Errorf("cork: can't register %s, as extension type 0x%02X is already registered to %s", t, e, o)
+	}
+
+	r.types[e] = t
+
+	return nil
+
+}
+
+// lookup returns the type registered with the extension type.
+func (r *Registry) lookup(e byte) (reflect.Type, bool) {
+	r.lock.RLock()
+	t, ok := r.types[e]
+	r.lock.RUnlock()
+	return t, ok
 }
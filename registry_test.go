@@ -0,0 +1,89 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Other struct {
+	Name string
+}
+
+func (this *Other) ExtendCORK() byte {
+	return 0x01
+}
+
+func (this *Other) MarshalCORK() ([]byte, error) {
+	return []byte(this.Name), nil
+}
+
+func (this *Other) UnmarshalCORK(src []byte) error {
+	this.Name = string(src)
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+
+	Convey("Registering a type twice has no effect", t, func() {
+		So(func() { Register(&Corked{}) }, ShouldNotPanic)
+	})
+
+	Convey("Registering a different type with the same byte panics", t, func() {
+		So(func() { Register(&Other{}) }, ShouldPanic)
+		So(DefaultRegistry.Add(&Other{}), ShouldNotBeNil)
+		So(Decode(Encode(&Other{Name: "test"})), ShouldHaveSameTypeAs, &Simple{})
+	})
+
+	Convey("Registering a type which is not a Corker or Selfer fails", t, func() {
+		So(NewRegistry().Add(&Tested{}), ShouldNotBeNil)
+		So(NewRegistry().Add(Corker(nil)), ShouldNotBeNil)
+		So(func() { NewRegistry().Register(&Tested{}) }, ShouldPanic)
+	})
+
+	Convey("A Registry on the Handle is used when decoding", t, func() {
+		var tmp interface{}
+		reg := NewRegistry()
+		So(reg.Add(&Other{}), ShouldBeNil)
+		So(NewDecoderBytes(Encode(&Other{Name: "test"})).Options(&Handle{Registry: reg}).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, &Other{Name: "test"})
+	})
+
+	Convey("Types can be registered whilst decoding", t, func() {
+		var wg sync.WaitGroup
+		reg := NewRegistry()
+		enc := Encode(&Selfed{Name: "test"})
+		reg.Register(&Selfed{})
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				reg.Register(&Corked{})
+			}()
+			go func() {
+				defer wg.Done()
+				var tmp interface{}
+				NewDecoderBytes(enc).Options(&Handle{Registry: reg}).Decode(&tmp)
+			}()
+		}
+		wg.Wait()
+		_, ok := reg.lookup(0x02)
+		So(ok, ShouldBeTrue)
+	})
+
+}
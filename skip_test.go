@@ -0,0 +1,131 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Oldest struct {
+	Name string
+}
+
+type Newest struct {
+	Name  string
+	Data  []byte
+	Temp  []interface{}
+	Test  map[string]interface{}
+	Self  *Selfed
+	Cork  *Corked
+	Count int
+}
+
+func TestSkip(t *testing.T) {
+
+	tme, _ := time.Parse(time.RFC3339, "1987-06-22T08:00:00.123456789Z")
+
+	obj := []interface{}{
+		nil,
+		true,
+		false,
+		str,
+		bin,
+		lng,
+		tme,
+		int(1),
+		int8(math.MinInt8),
+		int16(math.MinInt16),
+		int32(math.MinInt32),
+		int64(math.MinInt64),
+		uint16(math.MaxUint16),
+		uint32(math.MaxUint32),
+		uint64(math.MaxUint64),
+		float32(math.Pi),
+		float64(math.Pi),
+		complex64(math.Pi),
+		complex128(math.Pi),
+		[]interface{}{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15", "16", "17", "18"},
+		map[string]interface{}{"1": []interface{}{1, 2, 3}, "2": map[string]int{"test": 1}},
+		&Simple{},
+		&Corked{Name: "test", Data: []byte("test"), Test: map[string]string{"1": "2"}},
+		&Selfed{Name: "test", Data: []byte("test"), Test: map[string]string{"1": "2"}},
+		Tested{"test", []byte("test"), []string{"1", "2"}, map[string]string{"1": "2"}, false, 25, "", ""},
+	}
+
+	for _, v := range obj {
+
+		Convey(fmt.Sprintf("Can skip %T and stay in sync", v), t, func() {
+			var out string
+			bit := Encode(v)
+			all := append(append([]byte(nil), bit...), Encode("next")...)
+			dec := NewDecoderBytes(all)
+			So(dec.r.Skip(), ShouldResemble, bit)
+			So(dec.Decode(&out), ShouldBeNil)
+			So(out, ShouldEqual, "next")
+		})
+
+	}
+
+	Convey("Can skip multiple values", t, func() {
+		var out int
+		one := Encode("one")
+		two := Encode([]int{1, 2})
+		all := append(append(append([]byte(nil), one...), two...), 9)
+		dec := NewDecoderBytes(all)
+		So(dec.r.SkipN(2), ShouldResemble, append(one, two...))
+		So(dec.Decode(&out), ShouldBeNil)
+		So(out, ShouldEqual, 9)
+	})
+
+	Convey("Can not skip an invalid value", t, func() {
+		dec := NewDecoderBytes([]byte{cAlt})
+		So(func() { dec.r.Skip() }, ShouldPanic)
+	})
+
+	Convey("Can not skip an alternative value with an invalid body", t, func() {
+		for _, bit := range [][]byte{
+			append([]byte{cAlt, cAltDur}, Encode("test")...),
+			append([]byte{cAlt, cAltInt}, Encode([]byte{0xFF})...),
+		} {
+			dec := NewDecoderBytes(bit)
+			So(func() { dec.r.Skip() }, ShouldPanic)
+		}
+	})
+
+	Convey("Unknown struct fields are skipped when decoding", t, func() {
+		var tmp Oldest
+		var val = Newest{
+			Name:  "test",
+			Data:  []byte("test"),
+			Temp:  []interface{}{1, "2", 3.0},
+			Test:  map[string]interface{}{"1": []interface{}{true}},
+			Self:  &Selfed{Name: "test", Test: map[string]string{}, Temp: []string{}},
+			Cork:  &Corked{Name: "test", Test: map[string]string{}, Temp: []string{}},
+			Count: 25,
+		}
+		var arr []Oldest
+		bit := Encode([]Newest{val, val})
+		So(NewDecoderBytes(Encode(val)).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, Oldest{Name: "test"})
+		So(NewDecoderBytes(bit).Decode(&arr), ShouldBeNil)
+		So(arr, ShouldResemble, []Oldest{{Name: "test"}, {Name: "test"}})
+	})
+
+}
@@ -22,20 +22,46 @@ import (
 )
 
 type sortable struct {
+	h   *Handle
 	key []byte
+	enc []byte
+	src reflect.Value
 	val interface{}
 	ref reflect.Value
 }
 
-func sortMap(m reflect.Value) (a []*sortable) {
-	for _, k := range m.MapKeys() {
-		s := &sortable{ref: m.MapIndex(k)}
-		NewEncoderBytes(&s.key).w.EncodeReflect(k)
-		a = append(a, s)
+// value returns the encoded value of the map entry, which
+// is only needed when two different keys encode the same.
+func (s *sortable) value() []byte {
+	if s.enc == nil {
+		s.enc = []byte{}
+		if s.ref.IsValid() {
+			NewEncoderBytes(&s.enc).Options(s.h).w.EncodeReflect(s.ref)
+		} else {
+			NewEncoderBytes(&s.enc).Options(s.h).w.EncodeAny(s.val)
+		}
 	}
+	return s.enc
+}
+
+func sortKeys(a []*sortable) {
 	sort.Slice(a, func(x, y int) bool {
-		return bytes.Compare(a[x].key, a[y].key) < 0
+		if c := bytes.Compare(a[x].key, a[y].key); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(a[x].value(), a[y].value()) < 0
 	})
+}
+
+// sortMap sorts the entries of a map by their encoded keys,
+// which are encoded using the same Handle as the map itself.
+func sortMap(h *Handle, m reflect.Value) (a []*sortable) {
+	for _, k := range m.MapKeys() {
+		s := &sortable{h: h, src: k, ref: m.MapIndex(k)}
+		NewEncoderBytes(&s.key).Options(h).w.EncodeReflect(k)
+		a = append(a, s)
+	}
+	sortKeys(a)
 	return
 }
 
@@ -107,14 +133,12 @@ func sortMapTimeAny(m map[time.Time]interface{}) (a []time.Time) {
 	return
 }
 
-func sortMapAnyAny(m map[interface{}]interface{}) (a []*sortable) {
+func sortMapAnyAny(h *Handle, m map[interface{}]interface{}) (a []*sortable) {
 	for k, v := range m {
-		s := &sortable{val: v}
-		NewEncoderBytes(&s.key).w.EncodeAny(k)
+		s := &sortable{h: h, src: reflect.ValueOf(k), val: v}
+		NewEncoderBytes(&s.key).Options(h).w.EncodeAny(k)
 		a = append(a, s)
 	}
-	sort.Slice(a, func(x, y int) bool {
-		return bytes.Compare(a[x].key, a[y].key) < 0
-	})
+	sortKeys(a)
 	return
 }
@@ -0,0 +1,102 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Dated struct {
+	Name string
+	When time.Time
+}
+
+func TestTime(t *testing.T) {
+
+	zone := time.FixedZone("BST", 3600)
+	past := time.Date(1066, 10, 14, 9, 30, 0, 123456789, time.UTC)
+	next := time.Date(9999, 12, 31, 23, 59, 59, 999999999, time.UTC)
+	here := time.Date(1987, 6, 22, 9, 0, 0, 123456789, zone)
+
+	Convey("Times in UTC use the short form", t, func() {
+		enc := Encode(here.UTC())
+		So(enc, ShouldHaveLength, 9)
+		So(enc[0], ShouldEqual, cTime)
+	})
+
+	Convey("Times out of range use the long form", t, func() {
+		for _, val := range []time.Time{past, next} {
+			var tmp time.Time
+			enc := Encode(val)
+			So(enc[:2], ShouldResemble, []byte{cAlt, cAltTime})
+			DecodeInto(enc, &tmp)
+			So(tmp, ShouldResemble, val)
+			So(Decode(enc), ShouldResemble, val)
+		}
+	})
+
+	Convey("Times keep the offset and name of their zone", t, func() {
+		var tmp time.Time
+		enc := Encode(here)
+		So(enc[:2], ShouldResemble, []byte{cAlt, cAltTime})
+		DecodeInto(enc, &tmp)
+		So(tmp.Equal(here), ShouldBeTrue)
+		name, offset := tmp.Zone()
+		So(name, ShouldEqual, "BST")
+		So(offset, ShouldEqual, 3600)
+		So(tmp.Format(time.RFC3339Nano), ShouldEqual, here.Format(time.RFC3339Nano))
+	})
+
+	Convey("The zero time decodes as the zero time", t, func() {
+		var tmp = time.Now()
+		DecodeInto(Encode(time.Time{}), &tmp)
+		So(tmp, ShouldResemble, time.Time{})
+		So(tmp.IsZero(), ShouldBeTrue)
+		So(Decode(Encode(time.Time{})), ShouldResemble, time.Time{})
+	})
+
+	Convey("Long form times can be skipped", t, func() {
+		var tmp struct{ Name string }
+		So(NewDecoderBytes(Encode(Dated{Name: "test", When: past})).Decode(&tmp), ShouldBeNil)
+		So(tmp.Name, ShouldEqual, "test")
+	})
+
+	Convey("Long form times can be read in indefinite-length arrays", t, func() {
+		var tmp []time.Time
+		buf := bytes.NewBuffer(nil)
+		enc := NewEncoder(buf)
+		enc.BeginArray()
+		enc.Encode(past)
+		enc.Encode(next)
+		enc.EndArray()
+		So(NewDecoderBytes(buf.Bytes()).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldResemble, []time.Time{past, next})
+		So(Decode(buf.Bytes()), ShouldResemble, []interface{}{past, next})
+	})
+
+	Convey("Long form times can be read as tokens", t, func() {
+		r := newReader()
+		r.r.ResetBytes(Encode(past))
+		So(r.Peek(), ShouldEqual, TokenTime)
+		tok, err := r.Next()
+		So(err, ShouldBeNil)
+		So(tok, ShouldResemble, Token{Kind: TokenTime, Value: past})
+	})
+
+}
@@ -0,0 +1,92 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+// TokenKind represents the kind of a Token in a CORK stream.
+type TokenKind int
+
+const (
+	TokenNil TokenKind = iota
+	TokenBool
+	TokenInt
+	TokenUint
+	TokenFloat
+	TokenComplex
+	TokenTime
+	TokenStr
+	TokenBin
+	TokenExt
+	TokenArrBegin
+	TokenArrEnd
+	TokenMapBegin
+	TokenMapEnd
+	TokenSlfBegin
+	TokenSlfEnd
+)
+
+// TokenInvalid is returned by Reader.Peek when the next byte in
+// the stream does not begin any known kind of value.
+const TokenInvalid TokenKind = -1
+
+var tokenNames = [...]string{
+	TokenNil:      "nil",
+	TokenBool:     "bool",
+	TokenInt:      "int",
+	TokenUint:     "uint",
+	TokenFloat:    "float",
+	TokenComplex:  "complex",
+	TokenTime:     "time",
+	TokenStr:      "str",
+	TokenBin:      "bin",
+	TokenExt:      "ext",
+	TokenArrBegin: "arr-begin",
+	TokenArrEnd:   "arr-end",
+	TokenMapBegin: "map-begin",
+	TokenMapEnd:   "map-end",
+	TokenSlfBegin: "slf-begin",
+	TokenSlfEnd:   "slf-end",
+}
+
+func (k TokenKind) String() string {
+	if k >= 0 && int(k) < len(tokenNames) {
+		return tokenNames[k]
+	}
+	return "invalid"
+}
+
+// Token represents a single item in a CORK stream, as returned
+// by Reader.Next. Scalar values are returned whole, whereas arrays,
+// maps, and self-describing values are returned as a begin token,
+// followed by the tokens of their contents, and then an end token.
+type Token struct {
+	// Kind specifies the kind of item in the stream.
+	Kind TokenKind
+	// Len specifies the length in bytes of a str, bin,
+	// or ext token, the number of elements in an array,
+	// the number of key-value pairs in a map, or the
+	// number of values in a self-describing value. The
+	// Len of an indefinite-length array or map is -1.
+	Len int
+	// Ext specifies the extension type byte of an ext
+	// token, or of a self-describing value.
+	Ext byte
+	// Value holds the decoded value of a scalar token,
+	// which will be one of bool, int, uint, float32,
+	// float64, complex64, complex128, time.Time, string,
+	// or []byte (for bin and ext tokens). An int token
+	// may also hold a time.Duration or a *big.Int, and
+	// a float token a *big.Float or a *big.Rat.
+	Value interface{}
+}
@@ -0,0 +1,286 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func tokens(dec *Decoder) (out []Token, err error) {
+	for {
+		tok, err := dec.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		out = append(out, tok)
+	}
+}
+
+func TestTokens(t *testing.T) {
+
+	tme, _ := time.Parse(time.RFC3339, "1987-06-22T08:00:00.123456789Z")
+
+	Convey("Scalars will be read as tokens", t, func() {
+		var buf []byte
+		enc := NewEncoderBytes(&buf)
+		enc.Encode(nil)
+		enc.Encode(true)
+		enc.Encode(-1)
+		enc.Encode(uint(math.MaxUint16))
+		enc.Encode(float32(math.Pi))
+		enc.Encode(math.Pi)
+		enc.Encode(complex64(math.Pi))
+		enc.Encode(tme)
+		enc.Encode("test")
+		enc.Encode([]byte("test"))
+		enc.Encode(&Corked{Name: "test"})
+		out, err := tokens(NewDecoderBytes(buf))
+		So(err, ShouldBeNil)
+		So(out, ShouldResemble, []Token{
+			{Kind: TokenNil},
+			{Kind: TokenBool, Value: true},
+			{Kind: TokenInt, Value: -1},
+			{Kind: TokenUint, Value: uint(math.MaxUint16)},
+			{Kind: TokenFloat, Value: float32(math.Pi)},
+			{Kind: TokenFloat, Value: math.Pi},
+			{Kind: TokenComplex, Value: complex64(math.Pi)},
+			{Kind: TokenTime, Value: tme},
+			{Kind: TokenStr, Len: 4, Value: "test"},
+			{Kind: TokenBin, Len: 4, Value: []byte("test")},
+			{Kind: TokenExt, Len: 9, Ext: 0x02, Value: Encode(&Corked{Name: "test"})[2:]},
+		})
+	})
+
+	Convey("Arrays and maps will be read as tokens", t, func() {
+		var val = []interface{}{1, map[string]interface{}{"one": []int{}}, "two"}
+		out, err := tokens(NewDecoderBytes(Encode(val)))
+		So(err, ShouldBeNil)
+		So(out, ShouldResemble, []Token{
+			{Kind: TokenArrBegin, Len: 3},
+			{Kind: TokenInt, Value: 1},
+			{Kind: TokenMapBegin, Len: 1},
+			{Kind: TokenStr, Len: 3, Value: "one"},
+			{Kind: TokenArrBegin, Len: 0},
+			{Kind: TokenArrEnd},
+			{Kind: TokenMapEnd},
+			{Kind: TokenStr, Len: 3, Value: "two"},
+			{Kind: TokenArrEnd},
+		})
+	})
+
+	Convey("Self-describing values will be read as tokens", t, func() {
+		var val = []interface{}{&Selfed{Name: "test", Count: 25}, true}
+		out, err := tokens(NewDecoderFromPool(bytes.NewReader(Encode(val))))
+		So(err, ShouldBeNil)
+		So(out, ShouldResemble, []Token{
+			{Kind: TokenArrBegin, Len: 2},
+			{Kind: TokenSlfBegin, Len: 5, Ext: 0x03},
+			{Kind: TokenStr, Len: 4, Value: "test"},
+			{Kind: TokenBin, Len: 0, Value: []byte{}},
+			{Kind: TokenArrBegin, Len: 0},
+			{Kind: TokenArrEnd},
+			{Kind: TokenMapBegin, Len: 0},
+			{Kind: TokenMapEnd},
+			{Kind: TokenInt, Value: 25},
+			{Kind: TokenSlfEnd},
+			{Kind: TokenBool, Value: true},
+			{Kind: TokenArrEnd},
+		})
+	})
+
+	Convey("Truncated streams will return an error", t, func() {
+		bit := Encode([]interface{}{"one", "two"})
+		out, err := tokens(NewDecoderBytes(bit[:len(bit)-1]))
+		So(errors.Is(err, io.ErrUnexpectedEOF), ShouldBeTrue)
+		So(out, ShouldHaveLength, 2)
+	})
+
+	Convey("Invalid streams will return an error", t, func() {
+		_, err := tokens(NewDecoderBytes([]byte{cAlt}))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Invalid streams will peek as invalid tokens", t, func() {
+		r := newReader()
+		r.r.ResetBytes([]byte{cAlt, 0x7F})
+		So(r.Peek(), ShouldEqual, TokenInvalid)
+		So(r.Peek().String(), ShouldEqual, "invalid")
+	})
+
+}
+
+func TestTokenWriter(t *testing.T) {
+
+	Convey("Indefinite arrays will be written with a break", t, func() {
+		var buf []byte
+		enc := NewEncoderBytes(&buf)
+		So(enc.BeginArray(), ShouldBeNil)
+		So(enc.Encode(1), ShouldBeNil)
+		So(enc.Encode("two"), ShouldBeNil)
+		So(enc.EndArray(), ShouldBeNil)
+		So(buf, ShouldResemble, []byte{cArr, cNil, 1, cFixStr + 3, 't', 'w', 'o', cAlt, cAltBrk})
+	})
+
+	Convey("Indefinite maps will be written with a break", t, func() {
+		var buf []byte
+		enc := NewEncoderBytes(&buf)
+		So(enc.BeginMap(), ShouldBeNil)
+		So(enc.Encode("one"), ShouldBeNil)
+		So(enc.Encode(1), ShouldBeNil)
+		So(enc.EndMap(), ShouldBeNil)
+		So(buf, ShouldResemble, []byte{cMap, cNil, cFixStr + 3, 'o', 'n', 'e', 1, cAlt, cAltBrk})
+	})
+
+	Convey("Mismatched end tokens will return an error", t, func() {
+		var buf []byte
+		enc := NewEncoderBytes(&buf)
+		So(errors.Is(enc.EndArray(), unbegun), ShouldBeTrue)
+		So(enc.BeginArray(), ShouldBeNil)
+		So(errors.Is(enc.EndMap(), unbegun), ShouldBeTrue)
+		So(enc.EndArray(), ShouldBeNil)
+	})
+
+	Convey("Tokens can be copied from a Reader to a Writer", t, func() {
+		var buf []byte
+		var val = []interface{}{nil, true, -1, uint(300), 1.5, "one", []byte("two"), &Corked{Name: "test"},
+			map[string]interface{}{"one": []int{1, 2}}, &Selfed{Name: "test", Count: 25}}
+		var bit = Encode(val)
+		dec := NewDecoderBytes(bit)
+		enc := NewEncoderBytes(&buf)
+		for {
+			tok, err := dec.Next()
+			if err == io.EOF {
+				break
+			}
+			So(err, ShouldBeNil)
+			So(enc.EncodeToken(tok), ShouldBeNil)
+		}
+		So(buf, ShouldResemble, bit)
+	})
+
+}
+
+func TestIndefinite(t *testing.T) {
+
+	stream := func(fn func(enc *Encoder)) []byte {
+		var buf []byte
+		fn(NewEncoderBytes(&buf))
+		return buf
+	}
+
+	arr := stream(func(enc *Encoder) {
+		enc.BeginArray()
+		for i := 0; i < 20; i++ {
+			enc.Encode(i)
+		}
+		enc.EndArray()
+	})
+
+	Convey("Indefinite arrays will decode into typed slices", t, func() {
+		var tmp []int
+		So(NewDecoderBytes(arr).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldHaveLength, 20)
+		So(tmp[19], ShouldEqual, 19)
+	})
+
+	Convey("Indefinite arrays will decode into reflected slices", t, func() {
+		var tmp []*int
+		So(NewDecoderBytes(arr).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldHaveLength, 20)
+		So(*tmp[19], ShouldEqual, 19)
+	})
+
+	Convey("Indefinite arrays will decode into interfaces", t, func() {
+		var tmp interface{}
+		So(NewDecoderBytes(arr).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldHaveLength, 20)
+	})
+
+	Convey("Indefinite arrays will decode from a stream", t, func() {
+		var tmp []interface{}
+		So(NewDecoder(bytes.NewReader(arr)).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldHaveLength, 20)
+	})
+
+	Convey("Indefinite maps will decode into maps and structs", t, func() {
+		var tmp map[string]interface{}
+		var out Tested
+		bit := stream(func(enc *Encoder) {
+			enc.BeginMap()
+			enc.Encode("Name")
+			enc.Encode("test")
+			enc.Encode("Temp")
+			enc.BeginArray()
+			enc.Encode("1")
+			enc.EndArray()
+			enc.Encode("Test")
+			enc.BeginMap()
+			enc.Encode("1")
+			enc.Encode("2")
+			enc.EndMap()
+			enc.Encode("Other")
+			enc.BeginMap()
+			enc.EndMap()
+			enc.Encode("Count")
+			enc.Encode(25)
+			enc.EndMap()
+		})
+		So(NewDecoderBytes(bit).Decode(&tmp), ShouldBeNil)
+		So(tmp, ShouldHaveLength, 5)
+		So(NewDecoderBytes(bit).Decode(&out), ShouldBeNil)
+		So(out, ShouldResemble, Tested{Name: "test", Temp: []string{"1"}, Test: map[string]string{"1": "2"}, Count: 25})
+	})
+
+	Convey("Indefinite containers will be skipped and read as tokens", t, func() {
+		bit := stream(func(enc *Encoder) {
+			enc.BeginArray()
+			enc.BeginMap()
+			enc.Encode("one")
+			enc.Encode(1)
+			enc.EndMap()
+			enc.EndArray()
+			enc.Encode(true)
+		})
+		dec := NewDecoderBytes(bit)
+		So(dec.r.Skip(), ShouldResemble, bit[:len(bit)-1])
+		out, err := tokens(NewDecoderBytes(bit))
+		So(err, ShouldBeNil)
+		So(out, ShouldResemble, []Token{
+			{Kind: TokenArrBegin, Len: -1},
+			{Kind: TokenMapBegin, Len: -1},
+			{Kind: TokenStr, Len: 3, Value: "one"},
+			{Kind: TokenInt, Value: 1},
+			{Kind: TokenMapEnd},
+			{Kind: TokenArrEnd},
+			{Kind: TokenBool, Value: true},
+		})
+	})
+
+	Convey("Unterminated indefinite arrays will return an error", t, func() {
+		var tmp []int
+		So(NewDecoderBytes(arr[:len(arr)-1]).Decode(&tmp), ShouldNotBeNil)
+	})
+
+}
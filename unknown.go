@@ -0,0 +1,44 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+// UnknownExt holds a custom type which was found in the stream
+// when decoding into a nil interface, but for which no Corker type
+// is registered with its extension type byte. An UnknownExt is
+// encoded back into the stream exactly as it was read, so values
+// written by newer code can be passed through by older code.
+type UnknownExt struct {
+	// Type is the extension type byte.
+	Type byte
+	// Data is the binary data of the custom type.
+	Data []byte
+}
+
+// ExtendCORK returns the extension type byte of the value.
+func (u *UnknownExt) ExtendCORK() byte {
+	return u.Type
+}
+
+// MarshalCORK returns the binary data of the value.
+func (u *UnknownExt) MarshalCORK() ([]byte, error) {
+	return u.Data, nil
+}
+
+// UnmarshalCORK sets the binary data of the value, copying it
+// so that it does not alias the stream which it was read from.
+func (u *UnknownExt) UnmarshalCORK(src []byte) error {
+	u.Data = append([]byte{}, src...)
+	return nil
+}
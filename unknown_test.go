@@ -0,0 +1,74 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type Wrapper struct {
+	Name string
+	Ext  UnknownExt
+}
+
+func TestUnknown(t *testing.T) {
+
+	enc := []byte{cFixExt + 3, 0x40, 'a', 'b', 'c'}
+
+	Convey("Unregistered custom types decode as UnknownExt", t, func() {
+		So(Decode(enc), ShouldResemble, &UnknownExt{Type: 0x40, Data: []byte("abc")})
+	})
+
+	Convey("UnknownExt encodes byte for byte", t, func() {
+		So(Encode(Decode(enc)), ShouldResemble, enc)
+		So(Encode(UnknownExt{Type: 0x40, Data: []byte("abc")}), ShouldResemble, enc)
+	})
+
+	Convey("UnknownExt can be nested within other values", t, func() {
+		val := []interface{}{"a", &UnknownExt{Type: 0x40, Data: []byte("abc")}, map[interface{}]interface{}{"b": &UnknownExt{Type: 0x41, Data: []byte{}}}}
+		So(Decode(Encode(val)), ShouldResemble, val)
+	})
+
+	Convey("UnknownExt can be used as a struct field", t, func() {
+		var tmp Wrapper
+		val := Wrapper{Name: "test", Ext: UnknownExt{Type: 0x40, Data: []byte("abc")}}
+		DecodeInto(Encode(val), &tmp)
+		So(tmp, ShouldResemble, val)
+	})
+
+	Convey("UnknownExt does not alias the source", t, func() {
+		bit := append([]byte{}, enc...)
+		tmp := Decode(bit)
+		bit[2] = 'x'
+		So(tmp, ShouldResemble, &UnknownExt{Type: 0x40, Data: []byte("abc")})
+	})
+
+	Convey("Unregistered self-describing types fail to decode", t, func() {
+		var tmp interface{}
+		err := NewDecoderBytes([]byte{cSlf, 0x40, cTrue}).Decode(&tmp)
+		So(errors.Is(err, unregistered), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "0x40")
+	})
+
+	Convey("Unregistered self-describing types can't be skipped", t, func() {
+		var tmp Wrapper
+		err := NewDecoderBytes([]byte{cFixMap + 1, cFixStr + 1, 'X', cSlf, 0x40, cTrue}).Decode(&tmp)
+		So(errors.Is(err, unregistered), ShouldBeTrue)
+	})
+
+}
@@ -25,6 +25,8 @@ import (
 type Writer struct {
 	h *Handle
 	w *bump.Writer
+	t []frame
+	e error
 }
 
 func newWriter() *Writer {
@@ -83,6 +85,13 @@ func (w *Writer) writeLen(v uint) {
 	}
 }
 
+// full reports whether sized integers are encoded with
+// their full width, instead of in as few bytes as possible,
+// which is never the case when encoding in canonical form.
+func (w *Writer) full() bool {
+	return w.h != nil && w.h.FullPrecisionInts && !w.h.Canonical
+}
+
 func (w *Writer) writeLen8(val uint8) {
 	w.writeOne(byte(val))
 }
@@ -112,6 +121,51 @@ func (w *Writer) writeLen64(val uint64) {
 
 // ---------------------------------------------------------------------------
 
+/*
+Encode encodes 'src' to the Writer, in the same way as Encoder.Encode.
+Unlike the other Encode methods on the Writer, which panic when a value
+can not be encoded, Encode returns an error, so that it can be used
+safely from within a Selfer.
+
+Once an error has occurred, it is retained by the Writer, and is returned
+from any further calls to Encode, and from Err. An error which occurs within
+a Selfer is therefore also returned by the Encode call which is encoding
+that Selfer.
+
+Example:
+
+	func (s *Selfed) MarshalCORK(w *cork.Writer) error {
+		w.Encode(s.Name)
+		w.Encode(s.Data)
+		return w.Err()
+	}
+
+*/
+func (w *Writer) Encode(src interface{}) (err error) {
+	if w.e != nil {
+		return w.e
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			e := w.wrap(v)
+			if e.Type == nil && e.Path == "" {
+				e.Type = typeOf(src)
+			}
+			w.e = e
+			err = w.e
+		}
+	}()
+	w.EncodeAny(src)
+	return w.e
+}
+
+// Err returns the first error which occurred when calling Encode.
+func (w *Writer) Err() error {
+	return w.e
+}
+
+// ---------------------------------------------------------------------------
+
 // EncodeBool writes a nil value to the Writer.
 func (w *Writer) EncodeNil() {
 	w.writeOne(cNil)
@@ -126,7 +180,9 @@ func (w *Writer) EncodeBool(v bool) {
 	}
 }
 
-// EncodeByte encodes a byte value to the Writer.
+// EncodeByte writes a single raw byte to the Writer, without
+// any type information, so that it can only be read back by
+// DecodeByte. Use EncodeUint8 to encode a uint8 value.
 func (w *Writer) EncodeByte(v byte) {
 	w.writeOne(v)
 }
@@ -175,10 +231,31 @@ func (w *Writer) EncodeString(v string) {
 	w.writeText(v)
 }
 
+// EncodeRaw writes the encoded bytes of a cork.Raw value to the Writer.
+func (w *Writer) EncodeRaw(v Raw) {
+	if len(v) == 0 {
+		w.EncodeNil()
+		return
+	}
+	if w.canonical() {
+		w.writeMany(w.canonizeRaw(v))
+		return
+	}
+	w.writeMany(v)
+}
+
 // ---------------------------------------------------------------------------
 
 // EncodeInt encodes an int value to the Writer.
 func (w *Writer) EncodeInt(v int) {
+	if w.full() {
+		w.EncodeInt64(int64(v))
+		return
+	}
+	w.encodeInt(v)
+}
+
+func (w *Writer) encodeInt(v int) {
 	switch {
 	case v >= 0 && v <= fixedInt:
 		w.writeOne(byte(v))
@@ -210,28 +287,56 @@ func (w *Writer) EncodeInt(v int) {
 
 // EncodeInt8 encodes an int8 value to the Writer.
 func (w *Writer) EncodeInt8(v int8) {
-	w.EncodeInt(int(v))
+	if w.full() {
+		w.writeOne(cInt8)
+		w.writeLen8(uint8(v))
+		return
+	}
+	w.encodeInt(int(v))
 }
 
 // EncodeInt16 encodes an int16 value to the Writer.
 func (w *Writer) EncodeInt16(v int16) {
-	w.EncodeInt(int(v))
+	if w.full() {
+		w.writeOne(cInt16)
+		w.writeLen16(uint16(v))
+		return
+	}
+	w.encodeInt(int(v))
 }
 
 // EncodeInt32 encodes an int32 value to the Writer.
 func (w *Writer) EncodeInt32(v int32) {
-	w.EncodeInt(int(v))
+	if w.full() {
+		w.writeOne(cInt32)
+		w.writeLen32(uint32(v))
+		return
+	}
+	w.encodeInt(int(v))
 }
 
 // EncodeInt64 encodes an int64 value to the Writer.
 func (w *Writer) EncodeInt64(v int64) {
-	w.EncodeInt(int(v))
+	if w.full() {
+		w.writeOne(cInt64)
+		w.writeLen64(uint64(v))
+		return
+	}
+	w.encodeInt(int(v))
 }
 
 // ---------------------------------------------------------------------------
 
 // EncodeUint encodes a uint value to the Writer.
 func (w *Writer) EncodeUint(v uint) {
+	if w.full() {
+		w.EncodeUint64(uint64(v))
+		return
+	}
+	w.encodeUint(v)
+}
+
+func (w *Writer) encodeUint(v uint) {
 	switch {
 	case v >= 0 && v <= fixedInt:
 		w.writeOne(byte(v))
@@ -263,28 +368,51 @@ func (w *Writer) EncodeUint(v uint) {
 
 // EncodeUint8 encodes a uint8 value to the Writer.
 func (w *Writer) EncodeUint8(v uint8) {
-	w.EncodeUint(uint(v))
+	if w.full() {
+		w.writeOne(cUint8)
+		w.writeLen8(uint8(v))
+		return
+	}
+	w.encodeUint(uint(v))
 }
 
 // EncodeUint16 encodes a uint16 value to the Writer.
 func (w *Writer) EncodeUint16(v uint16) {
-	w.EncodeUint(uint(v))
+	if w.full() {
+		w.writeOne(cUint16)
+		w.writeLen16(uint16(v))
+		return
+	}
+	w.encodeUint(uint(v))
 }
 
 // EncodeUint32 encodes a uint32 value to the Writer.
 func (w *Writer) EncodeUint32(v uint32) {
-	w.EncodeUint(uint(v))
+	if w.full() {
+		w.writeOne(cUint32)
+		w.writeLen32(uint32(v))
+		return
+	}
+	w.encodeUint(uint(v))
 }
 
 // EncodeUint64 encodes a uint64 value to the Writer.
 func (w *Writer) EncodeUint64(v uint64) {
-	w.EncodeUint(uint(v))
+	if w.full() {
+		w.writeOne(cUint64)
+		w.writeLen64(uint64(v))
+		return
+	}
+	w.encodeUint(uint(v))
 }
 
 // ---------------------------------------------------------------------------
 
 // EncodeFloat32 encodes a float32 value to the Writer.
 func (w *Writer) EncodeFloat32(v float32) {
+	if w.canonical() {
+		v = canon32(v)
+	}
 	tmp := math.Float32bits(v)
 	w.writeOne(cFloat32)
 	w.writeOne(byte(tmp >> 24))
@@ -295,6 +423,9 @@ func (w *Writer) EncodeFloat32(v float32) {
 
 // EncodeFloat64 encodes a float64 value to the Writer.
 func (w *Writer) EncodeFloat64(v float64) {
+	if w.canonical() {
+		v = canon64(v)
+	}
 	tmp := math.Float64bits(v)
 	w.writeOne(cFloat64)
 	w.writeOne(byte(tmp >> 56))
@@ -311,6 +442,9 @@ func (w *Writer) EncodeFloat64(v float64) {
 
 // EncodeComplex64 encodes a complex64 value to the Writer.
 func (w *Writer) EncodeComplex64(v complex64) {
+	if w.canonical() {
+		v = complex(canon32(real(v)), canon32(imag(v)))
+	}
 	one := math.Float32bits(real(v))
 	two := math.Float32bits(imag(v))
 	w.writeOne(cComplex64)
@@ -326,6 +460,9 @@ func (w *Writer) EncodeComplex64(v complex64) {
 
 // EncodeComplex128 encodes a complex128 value to the Writer.
 func (w *Writer) EncodeComplex128(v complex128) {
+	if w.canonical() {
+		v = complex(canon64(real(v)), canon64(imag(v)))
+	}
 	one := math.Float64bits(real(v))
 	two := math.Float64bits(imag(v))
 	w.writeOne(cComplex128)
@@ -351,7 +488,13 @@ func (w *Writer) EncodeComplex128(v complex128) {
 
 // EncodeInt encodes a time.Time value to the Writer.
 func (w *Writer) EncodeTime(v time.Time) {
-	tmp := uint64(v.UTC().UnixNano())
+	z, o := v.Zone()
+	s := v.Unix()
+	if o != 0 || z != "UTC" || s <= minTime || s >= maxTime {
+		w.encodeTimeAlt(v, s, z, o)
+		return
+	}
+	tmp := uint64(v.UnixNano())
 	w.writeOne(cTime)
 	w.writeOne(byte(tmp >> 56))
 	w.writeOne(byte(tmp >> 48))
@@ -363,6 +506,26 @@ func (w *Writer) EncodeTime(v time.Time) {
 	w.writeOne(byte(tmp))
 }
 
+// EncodeDuration encodes a time.Duration value to the Writer.
+func (w *Writer) EncodeDuration(v time.Duration) {
+	w.writeOne(cAlt)
+	w.writeOne(cAltDur)
+	w.encodeInt(int(v))
+}
+
+// encodeTimeAlt encodes a time which is not in UTC, or which
+// can not be stored as nanoseconds since the epoch, as the
+// seconds since the epoch, the nanoseconds within the second,
+// and the offset and name of the zone.
+func (w *Writer) encodeTimeAlt(v time.Time, s int64, z string, o int) {
+	w.writeOne(cAlt)
+	w.writeOne(cAltTime)
+	w.writeLen64(uint64(s))
+	w.writeLen32(uint32(v.Nanosecond()))
+	w.writeLen32(uint32(int32(o)))
+	w.EncodeString(z)
+}
+
 // ---------------------------------------------------------------------------
 
 // EncodeArr encodes an array to the Writer.
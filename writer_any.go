@@ -16,6 +16,7 @@ package cork
 
 import (
 	"encoding"
+	"math/big"
 	"reflect"
 	"time"
 )
@@ -35,30 +36,34 @@ func (w *Writer) EncodeAny(v interface{}) {
 		w.EncodeNil()
 	case bool:
 		w.EncodeBool(v)
-	case byte:
-		w.EncodeByte(v)
+	case uint8:
+		w.EncodeUint8(v)
 	case []byte:
 		w.EncodeBytes(v)
+	case Raw:
+		w.EncodeRaw(v)
+	case UnknownExt:
+		w.EncodeCorker(&v)
 	case string:
 		w.EncodeString(v)
 	case int:
 		w.EncodeInt(v)
 	case int8:
-		w.EncodeInt(int(v))
+		w.EncodeInt8(v)
 	case int16:
-		w.EncodeInt(int(v))
+		w.EncodeInt16(v)
 	case int32:
-		w.EncodeInt(int(v))
+		w.EncodeInt32(v)
 	case int64:
-		w.EncodeInt(int(v))
+		w.EncodeInt64(v)
 	case uint:
 		w.EncodeUint(v)
 	case uint16:
-		w.EncodeUint(uint(v))
+		w.EncodeUint16(v)
 	case uint32:
-		w.EncodeUint(uint(v))
+		w.EncodeUint32(v)
 	case uint64:
-		w.EncodeUint(uint(v))
+		w.EncodeUint64(v)
 	case float32:
 		w.EncodeFloat32(v)
 	case float64:
@@ -69,6 +74,14 @@ func (w *Writer) EncodeAny(v interface{}) {
 		w.EncodeComplex128(v)
 	case time.Time:
 		w.EncodeTime(v)
+	case time.Duration:
+		w.EncodeDuration(v)
+	case *big.Int:
+		w.EncodeBigInt(v)
+	case *big.Float:
+		w.EncodeBigFloat(v)
+	case *big.Rat:
+		w.EncodeBigRat(v)
 
 	// -------------------------
 
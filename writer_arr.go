@@ -19,13 +19,27 @@ import (
 	"time"
 )
 
-func (w *Writer) encodeArr(a reflect.Value) {
+func (w *Writer) encodeArr(a reflect.Value, e *plan) {
 	w.encodeArrLen(a.Len())
+	p := step{elem: a.Type().Elem()}
+	defer w.trace(&p)
 	for i := 0; i < a.Len(); i++ {
-		w.EncodeReflect(a.Index(i))
+		p.indx = i
+		e.enc(w, a.Index(i))
 	}
 }
 
+// encodeArrFix encodes a fixed-size array of bytes,
+// writing it as binary data in the same way as a
+// byte slice.
+func (w *Writer) encodeArrFix(a reflect.Value) {
+	b := make([]byte, a.Len())
+	for i := range b {
+		b[i] = byte(a.Index(i).Uint())
+	}
+	w.EncodeBytes(b)
+}
+
 func (w *Writer) encodeArrBool(a []bool) {
 	w.encodeArrLen(len(a))
 	for _, v := range a {
@@ -43,28 +57,28 @@ func (w *Writer) encodeArrInt(a []int) {
 func (w *Writer) encodeArrInt8(a []int8) {
 	w.encodeArrLen(len(a))
 	for _, v := range a {
-		w.EncodeInt(int(v))
+		w.EncodeInt8(v)
 	}
 }
 
 func (w *Writer) encodeArrInt16(a []int16) {
 	w.encodeArrLen(len(a))
 	for _, v := range a {
-		w.EncodeInt(int(v))
+		w.EncodeInt16(v)
 	}
 }
 
 func (w *Writer) encodeArrInt32(a []int32) {
 	w.encodeArrLen(len(a))
 	for _, v := range a {
-		w.EncodeInt(int(v))
+		w.EncodeInt32(v)
 	}
 }
 
 func (w *Writer) encodeArrInt64(a []int64) {
 	w.encodeArrLen(len(a))
 	for _, v := range a {
-		w.EncodeInt(int(v))
+		w.EncodeInt64(v)
 	}
 }
 
@@ -78,28 +92,28 @@ func (w *Writer) encodeArrUint(a []uint) {
 func (w *Writer) encodeArrUint8(a []uint8) {
 	w.encodeArrLen(len(a))
 	for _, v := range a {
-		w.EncodeUint(uint(v))
+		w.EncodeUint8(v)
 	}
 }
 
 func (w *Writer) encodeArrUint16(a []uint16) {
 	w.encodeArrLen(len(a))
 	for _, v := range a {
-		w.EncodeUint(uint(v))
+		w.EncodeUint16(v)
 	}
 }
 
 func (w *Writer) encodeArrUint32(a []uint32) {
 	w.encodeArrLen(len(a))
 	for _, v := range a {
-		w.EncodeUint(uint(v))
+		w.EncodeUint32(v)
 	}
 }
 
 func (w *Writer) encodeArrUint64(a []uint64) {
 	w.encodeArrLen(len(a))
 	for _, v := range a {
-		w.EncodeUint(uint(v))
+		w.EncodeUint64(v)
 	}
 }
 
@@ -147,7 +161,10 @@ func (w *Writer) encodeArrTime(a []time.Time) {
 
 func (w *Writer) encodeArrAny(a []interface{}) {
 	w.encodeArrLen(len(a))
-	for _, v := range a {
+	var p step
+	defer w.trace(&p)
+	for i, v := range a {
+		p.indx = i
 		w.EncodeAny(v)
 	}
 }
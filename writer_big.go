@@ -0,0 +1,98 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+import (
+	"encoding/binary"
+	"math/big"
+)
+
+const (
+	bigZero byte = iota
+	bigFinite
+	bigInf
+)
+
+// EncodeBigInt encodes a big.Int value to the Writer.
+func (w *Writer) EncodeBigInt(v *big.Int) {
+	if v == nil {
+		w.EncodeNil()
+		return
+	}
+	b := []byte{bigSign(v.Sign() < 0)}
+	w.encodeBig(cAltInt, append(b, v.Bytes()...))
+}
+
+// EncodeBigFloat encodes a big.Float value to the Writer.
+func (w *Writer) EncodeBigFloat(v *big.Float) {
+	if v == nil {
+		w.EncodeNil()
+		return
+	}
+	b := make([]byte, 7, 16)
+	b[0] = byte(v.Mode())
+	binary.BigEndian.PutUint32(b[1:], uint32(v.Prec()))
+	b[5] = bigSign(v.Signbit())
+	switch {
+	case v.IsInf():
+		b[6] = bigInf
+	case v.Sign() == 0:
+		b[6] = bigZero
+	default:
+		// The mantissa is scaled up into an integer,
+		// and any trailing zero bits are moved into
+		// the exponent, so that each value has only
+		// one mantissa and exponent.
+		m := new(big.Float)
+		e := int64(v.MantExp(m)) - int64(v.Prec())
+		i, _ := m.SetMantExp(m, int(v.Prec())).Int(nil)
+		z := i.Abs(i).TrailingZeroBits()
+		x := make([]byte, 8)
+		binary.BigEndian.PutUint64(x, uint64(e+int64(z)))
+		b[6] = bigFinite
+		b = append(append(b, x...), i.Rsh(i, z).Bytes()...)
+	}
+	w.encodeBig(cAltFlt, b)
+}
+
+// EncodeBigRat encodes a big.Rat value to the Writer.
+func (w *Writer) EncodeBigRat(v *big.Rat) {
+	if v == nil {
+		w.EncodeNil()
+		return
+	}
+	n := v.Num().Bytes()
+	b := make([]byte, 5, 5+len(n))
+	b[0] = bigSign(v.Sign() < 0)
+	binary.BigEndian.PutUint32(b[1:], uint32(len(n)))
+	w.encodeBig(cAltRat, append(append(b, n...), v.Denom().Bytes()...))
+}
+
+// encodeBig writes an arbitrary-precision number as binary
+// data, holding the sign and big-endian magnitudes of the
+// number, along with the precision and rounding mode of a
+// big.Float, as described in the specification.
+func (w *Writer) encodeBig(e byte, v []byte) {
+	w.writeOne(cAlt)
+	w.writeOne(e)
+	w.EncodeBytes(v)
+}
+
+func bigSign(neg bool) byte {
+	if neg {
+		return 1
+	}
+	return 0
+}
@@ -15,23 +15,93 @@
 package cork
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"math"
+
+	"github.com/surrealdb/bump"
 )
 
-// EncodeSelfer encodes a cork.Selfer value to the Writer.
+// EncodeSelfer encodes a cork.Selfer value to the Writer. If the
+// FramedSelfers option is set on the Handle, then the value is
+// first encoded separately, so that it can be written with its
+// length, in the same way as a Corker. In canonical form, Selfers
+// are always framed, and their contents are rewritten into their
+// canonical form, as the Selfer may write a map in any order.
 func (w *Writer) EncodeSelfer(v Selfer) {
+	if w.h != nil && (w.h.FramedSelfers || w.h.Canonical) {
+		var b []byte
+		x := &Writer{h: w.h, w: bump.NewWriterBytes(&b)}
+		x.marshal(v)
+		if w.h.Canonical {
+			b = w.canonizeSelfer(v, b)
+		}
+		w.encodeExtLen(len(b))
+		w.writeOne(v.ExtendCORK())
+		w.writeMany(b)
+		return
+	}
 	w.writeOne(cSlf)
 	w.writeOne(v.ExtendCORK())
-	v.MarshalCORK(w)
+	w.marshal(v)
+}
+
+// marshal lets the Selfer encode its body to the Writer. If
+// the Selfer returns an error, then encoding is aborted, with
+// the error wrapped in an EncodeError which describes the
+// extension type and the type being encoded, unless the error
+// is already an EncodeError from a call to Encode within the
+// Selfer.
+func (w *Writer) marshal(v Selfer) {
+	if err := v.MarshalCORK(w); err != nil {
+		if e, ok := err.(*EncodeError); ok {
+			panic(e)
+		}
+		panic(&EncodeError{Type: typeOf(v), Err: fmt.Errorf("slf 0x%02X: %w", v.ExtendCORK(), err)})
+	}
+}
+
+// canonizeSelfer rewrites the contents of a Selfer into their
+// canonical form, failing if the contents can not be read back,
+// such as when the Selfer has used EncodeByte. Any other error
+// is passed on as it is.
+func (w *Writer) canonizeSelfer(v Selfer, b []byte) (d []byte) {
+	defer func() {
+		if e := recover(); e != nil {
+			if unreadable(e) {
+				panic(&EncodeError{Type: typeOf(v), Err: fmt.Errorf("slf 0x%02X: %w: %v", v.ExtendCORK(), noncanonical, e)})
+			}
+			panic(e)
+		}
+	}()
+	return canonizeAll(w.h, b)
+}
+
+// unreadable reports whether a value recovered from a panic
+// is an error from reading a value, rather than writing it.
+func unreadable(v interface{}) bool {
+	switch e := v.(type) {
+	case *DecodeError:
+		return true
+	case error:
+		return errors.Is(e, io.EOF) || errors.Is(e, io.ErrUnexpectedEOF)
+	}
+	return false
 }
 
 // EncodeCorker encodes a cork.Corker value to the Writer.
 func (w *Writer) EncodeCorker(v Corker) {
 	enc, err := v.MarshalCORK()
 	if err != nil {
-		panic(err)
+		panic(&EncodeError{Type: typeOf(v), Err: err})
 	}
-	sze := len(enc)
+	w.encodeExtLen(len(enc))
+	w.writeOne(v.ExtendCORK())
+	w.writeMany(enc)
+}
+
+func (w *Writer) encodeExtLen(sze int) {
 	switch {
 	case sze <= fixedExt:
 		w.writeOne(cFixExt + byte(sze))
@@ -48,6 +118,4 @@ func (w *Writer) EncodeCorker(v Corker) {
 		w.writeOne(cExt64)
 		w.writeLen64(uint64(sze))
 	}
-	w.writeOne(v.ExtendCORK())
-	w.writeMany(enc)
 }
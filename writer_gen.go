@@ -0,0 +1,35 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+// EncodeArrLen encodes the header of an array with n elements
+// to the Writer. Exactly n values must then be written to it.
+func (w *Writer) EncodeArrLen(n int) {
+	w.encodeArrLen(n)
+}
+
+// EncodeMapLen encodes the header of a map with n key-value
+// pairs to the Writer. Exactly n keys and n values must then
+// be written to it, with each key followed by its value.
+func (w *Writer) EncodeMapLen(n int) {
+	w.encodeMapLen(n)
+}
+
+// EncodeKey encodes the integer key of a struct field to the
+// Writer. Keys are always written in as few bytes as possible,
+// whether or not FullPrecisionInts is set on the Handle.
+func (w *Writer) EncodeKey(key int) {
+	w.encodeInt(key)
+}
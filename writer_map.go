@@ -15,26 +15,39 @@
 package cork
 
 import (
+	"bytes"
 	"reflect"
 	"time"
 )
 
-func (w *Writer) encodeMap(m reflect.Value) {
+func (w *Writer) encodeMap(m reflect.Value, x, e *plan) {
 	w.encodeMapLen(m.Len())
-	if w.h != nil && w.h.SortMaps {
-		for _, v := range sortMap(m) {
+	p := step{kind: stepKey, elem: m.Type().Elem()}
+	defer w.trace(&p)
+	if w.h != nil && (w.h.SortMaps || w.h.Canonical) {
+		a := sortMap(w.h, m)
+		for i, v := range a {
+			p.vkey = v.src
+			if w.h.Canonical && i > 0 && bytes.Equal(a[i-1].key, v.key) {
+				panic(duplicate)
+			}
 			w.writeMany(v.key)
-			w.EncodeReflect(v.ref)
+			e.enc(w, v.ref)
 		}
 	} else {
-		for _, k := range m.MapKeys() {
-			w.EncodeReflect(k)
-			w.EncodeReflect(m.MapIndex(k))
+		for i := m.MapRange(); i.Next(); {
+			p.vkey = i.Key()
+			x.enc(w, p.vkey)
+			e.enc(w, i.Value())
 		}
 	}
 }
 
 func (w *Writer) encodeMapStringInt(m map[string]int) {
+	if w.canonical() {
+		w.EncodeReflect(reflect.ValueOf(m))
+		return
+	}
 	w.encodeMapLen(len(m))
 	if w.h != nil && w.h.SortMaps {
 		for _, v := range sortMapStringInt(m) {
@@ -50,6 +63,10 @@ func (w *Writer) encodeMapStringInt(m map[string]int) {
 }
 
 func (w *Writer) encodeMapStringUint(m map[string]uint) {
+	if w.canonical() {
+		w.EncodeReflect(reflect.ValueOf(m))
+		return
+	}
 	w.encodeMapLen(len(m))
 	if w.h != nil && w.h.SortMaps {
 		for _, v := range sortMapStringUint(m) {
@@ -65,6 +82,10 @@ func (w *Writer) encodeMapStringUint(m map[string]uint) {
 }
 
 func (w *Writer) encodeMapStringBool(m map[string]bool) {
+	if w.canonical() {
+		w.EncodeReflect(reflect.ValueOf(m))
+		return
+	}
 	w.encodeMapLen(len(m))
 	if w.h != nil && w.h.SortMaps {
 		for _, v := range sortMapStringBool(m) {
@@ -80,6 +101,10 @@ func (w *Writer) encodeMapStringBool(m map[string]bool) {
 }
 
 func (w *Writer) encodeMapStringString(m map[string]string) {
+	if w.canonical() {
+		w.EncodeReflect(reflect.ValueOf(m))
+		return
+	}
 	w.encodeMapLen(len(m))
 	if w.h != nil && w.h.SortMaps {
 		for _, v := range sortMapStringString(m) {
@@ -95,14 +120,22 @@ func (w *Writer) encodeMapStringString(m map[string]string) {
 }
 
 func (w *Writer) encodeMapIntAny(m map[int]interface{}) {
+	if w.canonical() {
+		w.EncodeReflect(reflect.ValueOf(m))
+		return
+	}
 	w.encodeMapLen(len(m))
+	var p step
+	defer w.trace(&p)
 	if w.h != nil && w.h.SortMaps {
 		for _, v := range sortMapIntAny(m) {
+			p.indx = v
 			w.EncodeInt(v)
 			w.EncodeAny(m[v])
 		}
 	} else {
 		for k, v := range m {
+			p.indx = k
 			w.EncodeInt(k)
 			w.EncodeAny(v)
 		}
@@ -110,14 +143,22 @@ func (w *Writer) encodeMapIntAny(m map[int]interface{}) {
 }
 
 func (w *Writer) encodeMapUintAny(m map[uint]interface{}) {
+	if w.canonical() {
+		w.EncodeReflect(reflect.ValueOf(m))
+		return
+	}
 	w.encodeMapLen(len(m))
+	var p step
+	defer w.trace(&p)
 	if w.h != nil && w.h.SortMaps {
 		for _, v := range sortMapUintAny(m) {
+			p.indx = int(v)
 			w.EncodeUint(v)
 			w.EncodeAny(m[v])
 		}
 	} else {
 		for k, v := range m {
+			p.indx = int(k)
 			w.EncodeUint(k)
 			w.EncodeAny(v)
 		}
@@ -125,14 +166,22 @@ func (w *Writer) encodeMapUintAny(m map[uint]interface{}) {
 }
 
 func (w *Writer) encodeMapStringAny(m map[string]interface{}) {
+	if w.canonical() {
+		w.EncodeReflect(reflect.ValueOf(m))
+		return
+	}
 	w.encodeMapLen(len(m))
+	p := step{kind: stepKey}
+	defer w.trace(&p)
 	if w.h != nil && w.h.SortMaps {
 		for _, v := range sortMapStringAny(m) {
+			p.name = v
 			w.EncodeString(v)
 			w.EncodeAny(m[v])
 		}
 	} else {
 		for k, v := range m {
+			p.name = k
 			w.EncodeString(k)
 			w.EncodeAny(v)
 		}
@@ -140,14 +189,22 @@ func (w *Writer) encodeMapStringAny(m map[string]interface{}) {
 }
 
 func (w *Writer) encodeMapTimeAny(m map[time.Time]interface{}) {
+	if w.canonical() {
+		w.EncodeReflect(reflect.ValueOf(m))
+		return
+	}
 	w.encodeMapLen(len(m))
+	p := step{kind: stepKey}
+	defer w.trace(&p)
 	if w.h != nil && w.h.SortMaps {
 		for _, v := range sortMapTimeAny(m) {
+			p.vkey = reflect.ValueOf(v)
 			w.EncodeTime(v)
 			w.EncodeAny(m[v])
 		}
 	} else {
 		for k, v := range m {
+			p.vkey = reflect.ValueOf(k)
 			w.EncodeTime(k)
 			w.EncodeAny(v)
 		}
@@ -155,14 +212,22 @@ func (w *Writer) encodeMapTimeAny(m map[time.Time]interface{}) {
 }
 
 func (w *Writer) encodeMapAnyAny(m map[interface{}]interface{}) {
+	if w.canonical() {
+		w.EncodeReflect(reflect.ValueOf(m))
+		return
+	}
 	w.encodeMapLen(len(m))
+	p := step{kind: stepKey}
+	defer w.trace(&p)
 	if w.h != nil && w.h.SortMaps {
-		for _, v := range sortMapAnyAny(m) {
+		for _, v := range sortMapAnyAny(w.h, m) {
+			p.vkey = v.src
 			w.writeMany(v.key)
 			w.EncodeAny(v.val)
 		}
 	} else {
 		for k, v := range m {
+			p.vkey = reflect.ValueOf(k)
 			w.EncodeAny(k)
 			w.EncodeAny(v)
 		}
@@ -15,49 +15,50 @@
 package cork
 
 import (
+	"math/big"
 	"reflect"
 	"time"
 )
 
 // EncodeReflect encodes a reflect.Value value to the Writer.
 func (w *Writer) EncodeReflect(v reflect.Value) {
+	c.Plan(v.Type()).enc(w, v)
+}
+
+// encoder compiles the function which encodes values of
+// the type, checking the kind of the type, and whether
+// it implements the Selfer or Corker interfaces, once.
+func (c *cache) encoder(t reflect.Type) func(w *Writer, v reflect.Value) {
+
+	k := t.Kind()
 
 	// If the element is a function or a
 	// channel, then we can ignore these
 	// types as these are not able to be
-	// encoded. In addition, if the value
-	// is a nil pointer or interface then
-	// we can encode nil immediately.
-
-	k := v.Kind()
+	// encoded.
 
 	switch k {
 	case reflect.Func, reflect.Chan:
-		w.EncodeNil()
-		return
-	case reflect.Ptr, reflect.Interface:
-		if v.IsNil() {
+		return func(w *Writer, v reflect.Value) {
 			w.EncodeNil()
-			return
 		}
 	}
 
 	// Next let's check to see if the type
 	// implements either the Selfer or Corker
 	// interfaces, and if it does then encode
-	// it directly. Caching the interface
-	// detection speeds up the encoding.
-
-	t := v.Type()
+	// it directly.
 
 	if c.Selfable(t) {
-		w.EncodeSelfer(v.Interface().(Selfer))
-		return
+		return nilable(k, func(w *Writer, v reflect.Value) {
+			w.EncodeSelfer(v.Interface().(Selfer))
+		})
 	}
 
 	if c.Corkable(t) {
-		w.EncodeCorker(v.Interface().(Corker))
-		return
+		return nilable(k, func(w *Writer, v reflect.Value) {
+			w.EncodeCorker(v.Interface().(Corker))
+		})
 	}
 
 	// It wasn't a self describing interface
@@ -68,99 +69,276 @@ func (w *Writer) EncodeReflect(v reflect.Value) {
 	switch t {
 
 	case typeStr:
-		w.EncodeString(v.String())
-		return
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeString(v.String())
+		}
 
 	case typeBit:
-		w.EncodeBytes(v.Bytes())
-		return
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeBytes(v.Bytes())
+		}
+
+	case typeRaw:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeRaw(v.Bytes())
+		}
 
 	case typeTime:
-		w.EncodeTime(v.Interface().(time.Time))
-		return
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeTime(v.Interface().(time.Time))
+		}
+
+	case typeUnknown:
+		return func(w *Writer, v reflect.Value) {
+			x := v.Interface().(UnknownExt)
+			w.EncodeCorker(&x)
+		}
+
+	case typeDur:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeDuration(time.Duration(v.Int()))
+		}
+
+	case typeBigInt:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeBigInt(pointer(v).(*big.Int))
+		}
+
+	case typeBigFlt:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeBigFloat(pointer(v).(*big.Float))
+		}
+
+	case typeBigRat:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeBigRat(pointer(v).(*big.Rat))
+		}
 
 	}
 
 	// Otherwise let's switch over all of the
 	// possible types that this item can be
-	// and encode it into the correct type.
-	// For structs, we will cache the struct
-	// fields, so that we do not have to parse
-	// these for every item that we process.
+	// and encode it into the correct type,
+	// resolving the plans for any elements,
+	// keys, and struct fields up front.
 
 	switch k {
 
 	case reflect.Ptr:
-		w.EncodeReflect(v.Elem())
+		e := c.plan(t.Elem())
+		return nilable(k, func(w *Writer, v reflect.Value) {
+			e.enc(w, v.Elem())
+		})
 
 	case reflect.Map:
-		w.encodeMap(v)
+		x, e := c.plan(t.Key()), c.plan(t.Elem())
+		return func(w *Writer, v reflect.Value) {
+			w.encodeMap(v, x, e)
+		}
 
 	case reflect.Slice:
-		w.encodeArr(v)
+		e := c.plan(t.Elem())
+		return func(w *Writer, v reflect.Value) {
+			w.encodeArr(v, e)
+		}
+
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return func(w *Writer, v reflect.Value) {
+				w.encodeArrFix(v)
+			}
+		}
+		e := c.plan(t.Elem())
+		return func(w *Writer, v reflect.Value) {
+			w.encodeArr(v, e)
+		}
 
 	case reflect.Bool:
-		w.EncodeBool(v.Bool())
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeBool(v.Bool())
+		}
 
 	case reflect.String:
-		w.EncodeString(v.String())
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeString(v.String())
+		}
+
+	case reflect.Int:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeInt(int(v.Int()))
+		}
 
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		w.EncodeInt(int(v.Int()))
+	case reflect.Int8:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeInt8(int8(v.Int()))
+		}
 
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		w.EncodeUint(uint(v.Uint()))
+	case reflect.Int16:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeInt16(int16(v.Int()))
+		}
+
+	case reflect.Int32:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeInt32(int32(v.Int()))
+		}
+
+	case reflect.Int64:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeInt64(int64(v.Int()))
+		}
+
+	case reflect.Uint:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeUint(uint(v.Uint()))
+		}
+
+	case reflect.Uint8:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeUint8(uint8(v.Uint()))
+		}
+
+	case reflect.Uint16:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeUint16(uint16(v.Uint()))
+		}
+
+	case reflect.Uint32:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeUint32(uint32(v.Uint()))
+		}
+
+	case reflect.Uint64:
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeUint64(uint64(v.Uint()))
+		}
 
 	case reflect.Float32:
-		w.EncodeFloat32(float32(v.Float()))
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeFloat32(float32(v.Float()))
+		}
 
 	case reflect.Float64:
-		w.EncodeFloat64(v.Float())
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeFloat64(v.Float())
+		}
 
 	case reflect.Complex64:
-		w.EncodeComplex64(complex64(v.Complex()))
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeComplex64(complex64(v.Complex()))
+		}
 
 	case reflect.Complex128:
-		w.EncodeComplex128(v.Complex())
+		return func(w *Writer, v reflect.Value) {
+			w.EncodeComplex128(v.Complex())
+		}
 
 	case reflect.Interface:
-		w.EncodeAny(v.Interface())
+		return nilable(k, func(w *Writer, v reflect.Value) {
+			w.EncodeAny(v.Interface())
+		})
 
 	case reflect.Struct:
+		fls, err := c.fields(t)
+		if err != nil {
+			return func(w *Writer, v reflect.Value) {
+				panic(&EncodeError{Type: t, Err: err})
+			}
+		}
+		srt := sortFields(fls)
+		return func(w *Writer, v reflect.Value) {
+			w.encodeStruct(v, fls, srt)
+		}
 
-		if !c.Has(t) {
-			tot := 0
-			fls := make([]*field, t.NumField())
-			for i := 0; i < t.NumField(); i++ {
-				if f := newField(t.Field(i)); f != nil {
-					fls[tot] = f
-					tot++
-				}
+	}
+
+	return func(w *Writer, v reflect.Value) {}
+
+}
+
+// nilable wraps an encoder for a pointer or interface
+// type so that nil values are encoded as nil.
+func nilable(k reflect.Kind, fn func(w *Writer, v reflect.Value)) func(w *Writer, v reflect.Value) {
+	switch k {
+	case reflect.Ptr, reflect.Interface:
+		return func(w *Writer, v reflect.Value) {
+			if v.IsNil() {
+				w.EncodeNil()
+				return
 			}
-			c.Set(t, fls[:tot])
+			fn(w, v)
 		}
+	}
+	return fn
+}
 
-		sze, fls := 0, c.Get(t)
+// pointer returns a pointer to the value, using its address
+// if it is addressable, and otherwise a pointer to a copy.
+func pointer(v reflect.Value) interface{} {
+	if v.CanAddr() {
+		return v.Addr().Interface()
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p.Interface()
+}
 
+// encodeStruct encodes a struct as a map of its fields,
+// or as an array of its fields when StructAsArray is set.
+// In canonical form, the map is written with the fields
+// in srt, which are sorted by their encoded keys.
+func (w *Writer) encodeStruct(v reflect.Value, fls, srt []*field) {
+
+	sze := 0
+
+	p := step{kind: stepField}
+	defer w.trace(&p)
+
+	// Structs can be encoded as arrays, with
+	// the fields in the order of the struct,
+	// and none of them left out, so that
+	// they can be decoded by position.
+
+	if w.h != nil && w.h.StructAsArray {
+		w.encodeArrLen(len(fls))
 		for _, f := range fls {
-			if v := v.FieldByIndex(f.indx); v.IsValid() {
-				if !f.omit || (f.omit && !isEmpty(v)) {
-					sze++
-				}
+			p.name, p.elem = f.Name(), nil
+			if v := fieldOf(v, f.indx); v.IsValid() {
+				p.elem = v.Type()
+				f.val.enc(w, v)
+			} else {
+				w.EncodeNil()
 			}
 		}
+		return
+	}
+
+	for _, f := range fls {
+		if v := fieldOf(v, f.indx); v.IsValid() {
+			if !f.omit || !isEmpty(v) {
+				sze++
+			}
+		}
+	}
 
-		w.encodeMapLen(sze)
+	w.encodeMapLen(sze)
 
-		for _, f := range fls {
-			if v := v.FieldByIndex(f.indx); v.IsValid() {
-				if !f.omit || (f.omit && !isEmpty(v)) {
+	if w.canonical() {
+		fls = srt
+	}
+
+	for _, f := range fls {
+		if v := fieldOf(v, f.indx); v.IsValid() {
+			if !f.omit || !isEmpty(v) {
+				p.name, p.elem = f.Name(), v.Type()
+				if f.keyd {
+					w.encodeInt(f.key)
+				} else {
 					w.EncodeString(f.Name())
-					w.EncodeReflect(v)
 				}
+				f.val.enc(w, v)
 			}
 		}
-
 	}
 
 }
@@ -0,0 +1,121 @@
+// Copyright © SurrealDB Ltd
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cork
+
+// BeginArray starts an indefinite-length array on the Writer. Any
+// number of values can then be written, before the array is closed
+// using EndArray. This allows an array to be written before the
+// number of elements in it is known, but not in canonical form.
+func (w *Writer) BeginArray() {
+	if w.canonical() {
+		panic(unbounded)
+	}
+	w.writeOne(cArr)
+	w.writeOne(cNil)
+	w.t = append(w.t, frame{kind: TokenArrBegin, size: -1})
+}
+
+// EndArray closes the array which was most recently started.
+func (w *Writer) EndArray() {
+	w.end(TokenArrBegin)
+}
+
+// BeginMap starts an indefinite-length map on the Writer. Any number
+// of keys and values can then be written, before the map is closed
+// using EndMap. This allows a map to be written before the number of
+// entries in it is known, but not in canonical form.
+func (w *Writer) BeginMap() {
+	if w.canonical() {
+		panic(unbounded)
+	}
+	w.writeOne(cMap)
+	w.writeOne(cNil)
+	w.t = append(w.t, frame{kind: TokenMapBegin, size: -1})
+}
+
+// EndMap closes the map which was most recently started.
+func (w *Writer) EndMap() {
+	w.end(TokenMapBegin)
+}
+
+/*
+EncodeToken writes a single token to the Writer, allowing a stream
+to be written incrementally, or to be copied token by token from a
+Reader. Begin tokens with a Len of -1 start an indefinite-length array
+or map, in the same way as BeginArray and BeginMap. Begin tokens with
+a Len of 0 or more write the length up front, and the caller must then
+write exactly that many values before the matching end token.
+*/
+func (w *Writer) EncodeToken(t Token) {
+	switch t.Kind {
+	case TokenNil:
+		w.EncodeNil()
+	case TokenBool, TokenInt, TokenUint, TokenFloat, TokenComplex, TokenTime:
+		w.EncodeAny(t.Value)
+	case TokenStr:
+		s, _ := t.Value.(string)
+		w.EncodeString(s)
+	case TokenBin:
+		b, _ := t.Value.([]byte)
+		w.EncodeBytes(b)
+	case TokenExt:
+		b, _ := t.Value.([]byte)
+		w.encodeExtLen(len(b))
+		w.writeOne(t.Ext)
+		w.writeMany(b)
+	case TokenArrBegin:
+		if t.Len < 0 {
+			w.BeginArray()
+			return
+		}
+		w.encodeArrLen(t.Len)
+		w.t = append(w.t, frame{kind: TokenArrBegin, size: t.Len})
+	case TokenMapBegin:
+		if t.Len < 0 {
+			w.BeginMap()
+			return
+		}
+		w.encodeMapLen(t.Len)
+		w.t = append(w.t, frame{kind: TokenMapBegin, size: t.Len})
+	case TokenSlfBegin:
+		if w.canonical() {
+			panic(unbounded)
+		}
+		w.writeOne(cSlf)
+		w.writeOne(t.Ext)
+		w.t = append(w.t, frame{kind: TokenSlfBegin, size: t.Len})
+	case TokenArrEnd, TokenMapEnd, TokenSlfEnd:
+		// Each end kind directly
+		// follows its begin kind.
+		w.end(t.Kind - 1)
+	default:
+		panic(invalid)
+	}
+}
+
+// end closes the most recently started container, which
+// must be of the specified kind, writing a break marker if
+// the container was started with an indefinite length.
+func (w *Writer) end(k TokenKind) {
+	n := len(w.t)
+	if n == 0 || w.t[n-1].kind != k {
+		panic(unbegun)
+	}
+	if w.t[n-1].size < 0 {
+		w.writeOne(cAlt)
+		w.writeOne(cAltBrk)
+	}
+	w.t = w.t[:n-1]
+}